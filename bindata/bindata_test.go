@@ -0,0 +1,702 @@
+//
+// Copyright 2020 Alexander Saastamoinen
+//
+//  Licensed under the EUPL, Version 1.2 or – as soon they
+// will be approved by the European Commission - subsequent
+// versions of the EUPL (the "Licence");
+//  You may not use this work except in compliance with the
+// Licence.
+//  You may obtain a copy of the Licence at:
+//
+//  https://joinup.ec.europa.eu/collection/eupl/eupl-text-eupl-12
+//
+//  Unless required by applicable law or agreed to in
+// writing, software distributed under the Licence is
+// distributed on an "AS IS" basis,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied.
+//  See the Licence for the specific language governing
+// permissions and limitations under the Licence.
+//
+
+package bindata
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/tools/go/ast/inspector"
+
+	"github.com/miscing/embed/internal/testtree"
+)
+
+const (
+	testDir string = "../testdata/target/"
+)
+
+func walkTest() []*Entry {
+	var files []*Entry
+	if err := filepath.Walk(testDir, func(path string, info os.FileInfo, err error) error {
+		if testDir == path {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			panic(err)
+		}
+		rel, err := filepath.Rel(testDir, path)
+		if err != nil {
+			panic(err)
+		}
+		files = append(files, &Entry{File: f, Name: filepath.ToSlash(rel)})
+		return nil
+	}); err != nil {
+		panic(err)
+	}
+	return files
+}
+
+func TestMakeTar(t *testing.T) {
+	files := walkTest()
+	m := new(Maker)
+	out, err := m.MakeTar(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := tar.NewReader(out)
+	for {
+		h, err := r.Next()
+		if err == io.EOF {
+			return
+		} else if err != nil {
+			panic(err)
+		}
+		var mark bool
+		for _, f := range files {
+			if f.Name == filepath.Clean(h.Name) {
+				mark = true
+				break
+			}
+		}
+		if !mark {
+			t.Log("File not found", h.Name)
+			t.Log("Test set:")
+			for _, f := range files {
+				t.Log("\t", f.Name)
+			}
+			t.Fatal("function output had file name not found in testdata/target")
+		}
+		if h.Typeflag == tar.TypeDir && !strings.HasSuffix(h.Name, "/") {
+			t.Error("directory entry not marked as directory: ", h.Name)
+		}
+	}
+}
+
+func TestMakeTarDuplicate(t *testing.T) {
+	files := walkTest()
+	for _, f := range files {
+		if f.Name == "somedir/main.go" {
+			f.Name = "main.go"
+		}
+	}
+	if _, err := new(Maker).MakeTar(files); err == nil || !strings.Contains(err.Error(), "somedir/main.go") {
+		t.Error("two inputs with the same archive path did not fail with both paths: ", err)
+	}
+}
+
+func TestMakeTarSharedDir(t *testing.T) {
+	a := testtree.Make(t, map[string]string{"css/a.css": "a"})
+	b := testtree.Make(t, map[string]string{"css/b.css": "b"})
+	m := &Maker{Recurssive: true}
+	files, err := m.OpenFiles([]string{a, b})
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf, err := m.MakeTar(files)
+	if err != nil {
+		t.Fatal("directory shared by two roots failed: ", err)
+	}
+	var names []string
+	tr := tar.NewReader(buf)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, h.Name)
+	}
+	if want := "css/ css/a.css css/b.css"; strings.Join(names, " ") != want {
+		t.Errorf("archived %q, want %q", names, want)
+	}
+
+	c := testtree.Make(t, map[string]string{"css": "not a directory"})
+	if files, err = m.OpenFiles([]string{a, c}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.MakeTar(files); err == nil || !strings.Contains(err.Error(), "archive path css") {
+		t.Error("file colliding with a directory did not fail: ", err)
+	}
+}
+
+// reproducibleTar archives dir with Reproducible set.
+func reproducibleTar(t *testing.T, dir string) []byte {
+	m := &Maker{Recurssive: true, Reproducible: true}
+	files, err := m.OpenFiles([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := m.MakeTar(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
+
+func TestMakeTarReproducible(t *testing.T) {
+	tree := map[string]string{"b.txt": "b", "a/c.txt": "c", "a/d.sh": "d"}
+	first, second := testtree.Make(t, tree), testtree.Make(t, tree)
+	old := time.Date(2001, 2, 3, 4, 5, 6, 7, time.UTC)
+	if err := os.Chtimes(filepath.Join(second, "b.txt"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(filepath.Join(first, "a", "d.sh"), 0775); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(filepath.Join(second, "a", "d.sh"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SOURCE_DATE_EPOCH", "")
+	a, b := reproducibleTar(t, first), reproducibleTar(t, second)
+	if !bytes.Equal(a, b) {
+		t.Fatal("archives of identical content differ")
+	}
+
+	r := tar.NewReader(bytes.NewReader(b))
+	var names []string
+	for {
+		h, err := r.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, h.Name)
+		want := int64(0644)
+		if h.Typeflag == tar.TypeDir || h.Name == "a/d.sh" {
+			want = 0755
+		}
+		if h.Mode != want || h.Uid != 0 || h.Gid != 0 || h.Uname != "" || h.Gname != "" || !h.ModTime.Equal(time.Unix(0, 0)) {
+			t.Errorf("header of %s not normalised: mode %o, owner %d/%d %q/%q, mtime %v", h.Name, h.Mode, h.Uid, h.Gid, h.Uname, h.Gname, h.ModTime)
+		}
+	}
+	if want := "a/ a/c.txt a/d.sh b.txt"; strings.Join(names, " ") != want {
+		t.Errorf("entries %q, want %q", names, want)
+	}
+
+	// mtimes are clamped to SOURCE_DATE_EPOCH rather than replaced
+	t.Setenv("SOURCE_DATE_EPOCH", strconv.FormatInt(old.Add(time.Hour).Unix(), 10))
+	r = tar.NewReader(bytes.NewReader(reproducibleTar(t, second)))
+	for {
+		h, err := r.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		want := old.Add(time.Hour).Truncate(time.Second)
+		if h.Name == "b.txt" {
+			want = old.Truncate(time.Second)
+		}
+		if !h.ModTime.Equal(want) {
+			t.Errorf("mtime of %s is %v, want %v", h.Name, h.ModTime, want)
+		}
+	}
+
+	t.Setenv("SOURCE_DATE_EPOCH", "yesterday")
+	if _, err := new(Maker).MakeTar(walkTest()); err != nil {
+		t.Error("SOURCE_DATE_EPOCH read without Reproducible: ", err)
+	}
+	m := &Maker{Reproducible: true}
+	if _, err := m.MakeTar(walkTest()); err == nil {
+		t.Error("invalid SOURCE_DATE_EPOCH accepted")
+	}
+}
+
+func TestOpenFilesErrors(t *testing.T) {
+	dir := testtree.Make(t, map[string]string{"ok.txt": "ok"})
+	for _, l := range []string{"broken0", "broken1"} {
+		if err := os.Symlink(filepath.Join(dir, "missing"), filepath.Join(dir, l)); err != nil {
+			t.Skip(err)
+		}
+	}
+	missing := filepath.Join(dir, "nosuchdir")
+	files, err := new(Maker).OpenFiles([]string{dir, missing})
+	if err == nil {
+		t.Fatal("unreadable files not reported")
+	}
+	if files != nil {
+		t.Error("files returned with error")
+	}
+	for _, p := range []string{"broken0", "broken1", missing} {
+		if !strings.Contains(err.Error(), p) {
+			t.Error("error does not mention ", p, ": ", err)
+		}
+	}
+	var pathErr *os.PathError
+	if !errors.As(err, &pathErr) {
+		t.Error("error does not wrap *os.PathError: ", err)
+	}
+}
+
+func TestMakeSource(t *testing.T) {
+	const (
+		fName = "bindata"
+		pName = "main"
+	)
+	files := walkTest()
+	m := new(Maker)
+	out, err := m.MakeTar(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := m.MakeSource(out, pName, fName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), "", payload, 0)
+	if err != nil {
+		t.Log(err)
+		t.Fatal("function output not valid go code")
+	}
+	inspector.New([]*ast.File{f}).Preorder([]ast.Node{
+		new(ast.FuncDecl),
+		new(ast.File),
+	}, func(n ast.Node) {
+		switch k := n.(type) {
+		case *ast.FuncDecl:
+			if k.Name.Name != fName {
+				t.Error("output func name: ", k.Name.Name)
+			}
+		case *ast.File:
+			if k.Name.Name != pName {
+				t.Error("output file name: ", k.Name.Name)
+			}
+		}
+	})
+}
+
+// unquoteSum evaluates a sum of string literals as written by writeStringLiteral.
+func unquoteSum(t *testing.T, e ast.Expr) string {
+	switch k := e.(type) {
+	case *ast.ParenExpr:
+		return unquoteSum(t, k.X)
+	case *ast.BinaryExpr:
+		return unquoteSum(t, k.X) + unquoteSum(t, k.Y)
+	case *ast.BasicLit:
+		s, err := strconv.Unquote(k.Value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	t.Fatalf("unexpected expression %T in string literal", e)
+	return ""
+}
+
+func TestWriteStringLiteral(t *testing.T) {
+	raw := []byte("plain \"quoted\" \\ tab\t nl\n äö \ufeff \x00\xff\x7f")
+	for i := 0; i < 1024; i++ {
+		raw = append(raw, byte(i*7))
+	}
+	buf := new(bytes.Buffer)
+	writeStringLiteral(buf, raw)
+	e, err := parser.ParseExpr(buf.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := unquoteSum(t, e); got != string(raw) {
+		t.Errorf("string literal does not decode to its input\nExpected: %q\nGot: %q", raw, got)
+	}
+	for _, l := range strings.Split(buf.String(), "\n") {
+		if strings.ContainsRune(l, '\uFEFF') {
+			t.Error("byte order mark written as is")
+		}
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	for _, c := range []struct {
+		pattern, name string
+		match         bool
+	}{
+		{"*.map", "app.js.map", true},
+		{"*.map", "js/app.js.map", false},
+		{"**/*.map", "js/app.js.map", true},
+		{"**/*.map", "app.js.map", true},
+		{"**/node_modules", "a/b/node_modules", true},
+		{"js/**", "js/a/b.js", true},
+		{"js/**/b.js", "js/b.js", true},
+		{"js/**/b.js", "js/a/c/b.js", true},
+		{"js/**/b.js", "css/a/b.js", false},
+		{"**/*_test.go", "pkg/a_test.go", true},
+		{"**/*_test.go", "pkg/a.go", false},
+	} {
+		got, err := matchGlob(c.pattern, c.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != c.match {
+			t.Errorf("matchGlob(%q, %q) = %v, expected %v", c.pattern, c.name, got, c.match)
+		}
+	}
+	if _, err := matchGlob("[", "a"); err == nil {
+		t.Error("bad pattern not reported")
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	dir := t.TempDir()
+	var src, bench bytes.Buffer
+	res, err := Generate(context.Background(), Options{
+		Paths:       []string{testDir},
+		Name:        "assets",
+		PackageName: "assets",
+		Dir:         dir,
+		Recursive:   true,
+		Bench:       true,
+		Output:      &src,
+		BenchOutput: &bench,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Archived || res.PackageName != "assets" || len(res.Files) != 0 || res.Size == 0 || res.Size != res.StoredSize {
+		t.Errorf("unexpected result %+v", res)
+	}
+	for _, b := range []*bytes.Buffer{&src, &bench} {
+		if _, err := parser.ParseFile(token.NewFileSet(), "", b, 0); err != nil {
+			t.Error("output not valid go code: ", err)
+		}
+	}
+
+	res, err = Generate(context.Background(), Options{
+		Paths:       []string{testDir + "something.txt"},
+		PackageName: "assets",
+		Dir:         dir,
+		Encoding:    "asm",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(dir, "bindata.s"), filepath.Join(dir, "bindata.go")}
+	if res.Archived || strings.Join(res.Files, " ") != strings.Join(want, " ") {
+		t.Errorf("unexpected result %+v", res)
+	}
+	for _, f := range want {
+		if _, err := os.Stat(f); err != nil {
+			t.Error(err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Generate(ctx, Options{Paths: []string{testDir}, PackageName: "assets", Output: ioutil.Discard}); !errors.Is(err, context.Canceled) {
+		t.Error("cancelled context not reported: ", err)
+	}
+	if _, err := Generate(context.Background(), Options{Paths: []string{testDir}, PackageName: "assets", Encoding: "asm", Output: ioutil.Discard}); err == nil {
+		t.Error("asm encoding without AsmOutput did not fail")
+	}
+}
+
+func TestCompressionLevel(t *testing.T) {
+	raw := bytes.Repeat([]byte("compressible "), 100)
+	for _, c := range []struct {
+		level  int
+		shrink bool
+	}{{0, true}, {-1, true}, {NoCompression, false}, {9, true}, {-2, true}} {
+		m := &Maker{Compression: "flate", Level: c.level}
+		buf, err := m.MakeCompressed(bytes.NewBuffer(raw))
+		if err != nil {
+			t.Fatal(c.level, ": ", err)
+		}
+		if shrunk := buf.Len() < len(raw); shrunk != c.shrink {
+			t.Errorf("level %d compressed %d to %d bytes", c.level, len(raw), buf.Len())
+		}
+	}
+	for _, level := range []int{-4, 10} {
+		if _, err := (&Maker{Compression: "gzip", Level: level}).MakeCompressed(bytes.NewBuffer(raw)); err == nil {
+			t.Errorf("level %d accepted", level)
+		}
+	}
+
+	src := testtree.Make(t, map[string]string{"a.txt": string(raw)})
+	dir := t.TempDir()
+	if _, err := Generate(context.Background(), Options{Paths: []string{src}, PackageName: "assets", Dir: dir, Compression: "gzip"}); err != nil {
+		t.Fatal(err)
+	}
+	p, err := ReadSource(filepath.Join(dir, "bindata.go"), "bindata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Stored) >= len(p.Data) {
+		t.Errorf("zero level stored %d bytes of %d", len(p.Stored), len(p.Data))
+	}
+}
+
+func TestReadSource(t *testing.T) {
+	raw := []byte("\x00binary\xff\"quoted\"\n\uFEFFand more than a line of it, " + strings.Repeat("x", 300))
+	for _, enc := range []string{"bytes", "string", "asm"} {
+		for _, comp := range []string{"", "gzip", "zlib", "flate"} {
+			dir := t.TempDir()
+			m := &Maker{Encoding: enc, Compression: comp}
+			stored := bytes.NewBuffer(raw)
+			if comp != "" {
+				var err error
+				if stored, err = m.MakeCompressed(bytes.NewBuffer(raw)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want := append([]byte(nil), stored.Bytes()...)
+			if enc == "asm" {
+				asm, err := m.MakeAsm(bytes.NewBuffer(want), "data")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := writeFile(filepath.Join(dir, "data.s"), asm); err != nil {
+					t.Fatal(err)
+				}
+			}
+			src, err := m.MakeSource(stored, "assets", "data")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := writeFile(filepath.Join(dir, "data.go"), src); err != nil {
+				t.Fatal(err)
+			}
+			p, err := ReadSource(filepath.Join(dir, "data.go"), "data")
+			if err != nil {
+				t.Fatal(enc, comp, ": ", err)
+			}
+			if p.Encoding != enc || p.Compression != comp || p.Archived || !bytes.Equal(p.Stored, want) || !bytes.Equal(p.Data, raw) {
+				t.Errorf("%s %s: read back %s %q archived %v, %d stored bytes: %q", enc, comp, p.Encoding, p.Compression, p.Archived, len(p.Stored), p.Data)
+			}
+			if names, err := SourceNames(filepath.Join(dir, "data.go")); err != nil || len(names) != 1 || names[0] != "data" {
+				t.Errorf("%s %s: source names %q, %v", enc, comp, names, err)
+			}
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	src := testtree.Make(t, map[string]string{"a.txt": "a", "b.txt": "b", "c/d.txt": "d"})
+	dir := t.TempDir()
+	opts := Options{Paths: []string{src}, PackageName: "assets", Dir: dir, Recursive: true, Reproducible: true, Compression: "gzip"}
+	check := opts
+	check.Check = true
+	if _, err := Generate(context.Background(), check); err == nil {
+		t.Fatal("missing file not reported")
+	}
+	if _, err := Generate(context.Background(), opts); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Generate(context.Background(), check); err != nil {
+		t.Fatal("fresh output reported: ", err)
+	}
+
+	if err := os.Remove(filepath.Join(src, "a.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(src, "b.txt"), []byte("changed"), 0664); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(src, "c", "e.txt"), []byte("e"), 0664); err != nil {
+		t.Fatal(err)
+	}
+	before, err := os.ReadFile(filepath.Join(dir, "bindata.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = Generate(context.Background(), check)
+	var drift *DriftError
+	if !errors.As(err, &drift) {
+		t.Fatal("drift not reported: ", err)
+	}
+	got := fmt.Sprint(drift.Files, drift.Added, drift.Removed, drift.Changed)
+	if want := fmt.Sprint([]string{filepath.Join(dir, "bindata.go")}, []string{"c/e.txt"}, []string{"a.txt"}, []string{"b.txt"}); got != want {
+		t.Errorf("drift %s, want %s", got, want)
+	}
+	if after, err := os.ReadFile(filepath.Join(dir, "bindata.go")); err != nil || !bytes.Equal(before, after) {
+		t.Error("check modified the generated file: ", err)
+	}
+}
+
+func TestIncremental(t *testing.T) {
+	src := testtree.Make(t, map[string]string{"a.txt": "a", "b.txt": "b"})
+	dir := t.TempDir()
+	out := filepath.Join(dir, "bindata.go")
+	opts := Options{Paths: []string{src}, PackageName: "assets", Dir: dir}
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	generate := func(opts Options, wantUnchanged bool) {
+		t.Helper()
+		if err := os.Chtimes(out, old, old); err != nil && !errors.Is(err, os.ErrNotExist) {
+			t.Fatal(err)
+		}
+		res, err := Generate(context.Background(), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fi, err := os.Stat(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Unchanged != wantUnchanged || fi.ModTime().Equal(old) != wantUnchanged {
+			t.Errorf("unchanged %v, mtime kept %v, want %v", res.Unchanged, fi.ModTime().Equal(old), wantUnchanged)
+		}
+	}
+	generate(opts, false)
+	generate(opts, true)
+	if hash, err := readInputHash(out); err != nil || !strings.HasPrefix(hash, "sha256:") {
+		t.Errorf("no input hash recorded: %q %v", hash, err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(src, "b.txt"), []byte("changed"), 0664); err != nil {
+		t.Fatal(err)
+	}
+	generate(opts, false)
+	generate(opts, true)
+	opts.Compression = "gzip"
+	generate(opts, false)
+	opts.Force = true
+	generate(opts, false)
+}
+
+func TestWatch(t *testing.T) {
+	src := testtree.Make(t, map[string]string{"a.txt": "a", "b.txt": "b", "c.txt": "c"})
+	dir := t.TempDir()
+	type event struct {
+		changed []string
+		res     Result
+		err     error
+	}
+	events := make(chan event)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- Watch(ctx, Options{Paths: []string{src}, PackageName: "assets", Dir: dir, Exclude: []string{"*.log"}}, 10*time.Millisecond, func(changed []string, res Result, err error) {
+			events <- event{changed, res, err}
+		})
+	}()
+	next := func() event {
+		t.Helper()
+		select {
+		case e := <-events:
+			if e.err != nil {
+				t.Fatal(e.err)
+			}
+			return e
+		case <-time.After(5 * time.Second):
+			t.Fatal("no regeneration")
+		}
+		return event{}
+	}
+	if e := next(); e.changed != nil || e.res.Unchanged {
+		t.Errorf("unexpected first generation %+v", e)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(src, "a.txt"), []byte("changed"), 0664); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(src, "b.txt")); err != nil {
+		t.Fatal(err)
+	}
+	e := next()
+	want := []string{filepath.Join(src, "a.txt"), filepath.Join(src, "b.txt")}
+	if strings.Join(e.changed, " ") != strings.Join(want, " ") || e.res.Unchanged {
+		t.Errorf("changed %q unchanged %v, want %q regenerated", e.changed, e.res.Unchanged, want)
+	}
+	if p, err := ReadSource(filepath.Join(dir, "bindata.go"), "bindata"); err != nil || !bytes.Contains(p.Data, []byte("changed")) {
+		t.Error("regenerated file does not hold the change: ", err)
+	}
+
+	for _, name := range []string{".a.txt.swp", "debug.log"} { //left out of the archive
+		if err := ioutil.WriteFile(filepath.Join(src, name), []byte("x"), 0664); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+	if err := ioutil.WriteFile(filepath.Join(src, "c.txt"), []byte("changed"), 0664); err != nil {
+		t.Fatal(err)
+	}
+	if e := next(); len(e.changed) != 1 || e.changed[0] != filepath.Join(src, "c.txt") {
+		t.Errorf("changed %q, want only c.txt", e.changed)
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Error("Watch did not return the context error: ", err)
+	}
+}
+
+func TestIdentifiers(t *testing.T) {
+	names := []string{"static/css/main.css", "main.css", "main.css.data", "a-b.txt", "a_b.txt", "files", "---", "404.html"}
+	want := []string{"dStaticCssMainCss", "dMainCss", "dMainCssData2", "dABTxt", "dABTxt2", "dFiles2", "dFile", "d404Html"}
+	got := naming{prefix: "d", suffixes: []string{"", "Data"}, reserved: []string{"dFiles"}}.idents(names)
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("identifiers %q, want %q", got, want)
+	}
+
+	want = []string{"AStaticCSSMainCSS", "AMainCSS", "AMainCSSData", "AABTxt", "AABTxt2", "AFiles", "AFile", "A404HTML"}
+	got = naming{prefix: "A", initialisms: true, suffixes: []string{""}}.idents(names)
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("identifiers with initialisms %q, want %q", got, want)
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	targets, err := ParseConfig("embed.json", []byte(`{"targets": [
+	{"name": "assets", "paths": ["static"], "recursive": true, "include": ["*.css"], "encoding": "string"},
+	{"groups": [{"name": "schema", "paths": ["schema.sql"]}], "output": "schema.go", "level": 0}
+]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 2 || targets[0].Name != "assets" || targets[0].Level != 0 || targets[1].Level != NoCompression || !targets[0].Recursive || targets[0].Include[0] != "*.css" ||
+		targets[1].Groups[0].Paths[0] != "schema.sql" || targets[1].FileName != "schema.go" {
+		t.Errorf("parsed %+v", targets)
+	}
+
+	for _, c := range []struct{ config, err string }{
+		{`[]`, "embed.json:1:1: the config must be an object"},
+		{`{"target": []}`, `embed.json:1:2: unknown key "target", expected targets`},
+		{`{"targets": []}`, "embed.json:1:1: no targets"},
+		{"{\"targets\": [\n\t{\"paths\": [\"a\"], \"recursve\": true}]}", `embed.json:2:19: unknown key "recursve"`},
+		{"{\"targets\": [\n\t{\"paths\": [\"a\", 5]}]}", "embed.json:2:18: paths: cannot use number as string"},
+		{`{"targets": [{"paths": ["a"], "level": "high"}]}`, "embed.json:1:40: level: cannot use string as int"},
+		{`{"targets": [{"paths": ["a"], "level": 12}]}`, "embed.json:1:40: compression level 12 out of range -2 to 9"},
+		{`{"targets": [{"name": "a-b", "paths": ["a"]}]}`, `embed.json:1:23: name "a-b" is not a Go identifier`},
+		{`{"targets": [{"paths": ["a"], "compress": "lz4"}]}`, `embed.json:1:43: unknown compression "lz4", expected gzip, zlib or flate`},
+		{`{"targets": [{"name": "a"}]}`, "embed.json:1:14: target has no paths or groups"},
+		{`{"targets": [{"paths": ["a"]}, {"paths": ["b"], "output": "bindata.go"}]}`, "embed.json:1:59: target writes bindata.go like target 1"},
+		{`{"targets": [{"paths": ["a"] "fs": true}]}`, "embed.json:1:30: invalid character '\"' after object key:value pair"},
+	} {
+		if _, err := ParseConfig("embed.json", []byte(c.config)); err == nil || err.Error() != c.err {
+			t.Errorf("%s: error %v, want %s", c.config, err, c.err)
+		}
+	}
+}
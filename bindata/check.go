@@ -0,0 +1,129 @@
+//
+// Copyright 2020 Alexander Saastamoinen
+//
+//  Licensed under the EUPL, Version 1.2 or – as soon they
+// will be approved by the European Commission - subsequent
+// versions of the EUPL (the "Licence");
+//  You may not use this work except in compliance with the
+// Licence.
+//  You may obtain a copy of the Licence at:
+//
+//  https://joinup.ec.europa.eu/collection/eupl/eupl-text-eupl-12
+//
+//  Unless required by applicable law or agreed to in
+// writing, software distributed under the Licence is
+// distributed on an "AS IS" basis,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied.
+//  See the Licence for the specific language governing
+// permissions and limitations under the Licence.
+//
+
+package bindata
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// DriftError is returned by Generate with Options.Check set when the
+// generated files on disk differ from what would be generated.
+type DriftError struct {
+	Files   []string // generated files that are missing or differ
+	Added   []string // archive entries missing from the existing file
+	Removed []string // archive entries no longer generated
+	Changed []string // archive entries whose content or header differ
+}
+
+func (e *DriftError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s out of date", strings.Join(e.Files, ", "))
+	if n := len(e.Added) + len(e.Removed) + len(e.Changed); n > 0 {
+		fmt.Fprintf(&b, ": %d added, %d removed, %d changed", len(e.Added), len(e.Removed), len(e.Changed))
+	}
+	for _, l := range []struct {
+		verb  string
+		names []string
+	}{{"added", e.Added}, {"removed", e.Removed}, {"changed", e.Changed}} {
+		for _, name := range l.names {
+			fmt.Fprintf(&b, "\n\t%s %s", l.verb, name)
+		}
+	}
+	return b.String()
+}
+
+// check compares the generated outputs to the existing files in the file
+// system. data is the archive or file the outputs hold, listed against the
+// existing data when they differ.
+func check(outputs []output, goFile, name string, data []byte, archived bool) error {
+	drift := new(DriftError)
+	for _, o := range outputs {
+		old, err := os.ReadFile(o.name)
+		if errors.Is(err, os.ErrNotExist) {
+			drift.Files = append(drift.Files, o.name)
+			continue
+		} else if err != nil {
+			return err
+		}
+		if !bytes.Equal(old, o.buf.Bytes()) {
+			drift.Files = append(drift.Files, o.name)
+		}
+	}
+	if len(drift.Files) == 0 {
+		return nil
+	}
+	old, err := ReadSource(goFile, name)
+	if err != nil {
+		// missing or unreadable, nothing to list against
+		return drift
+	}
+	if !archived || (!old.Archived && old.Files == nil) {
+		if !bytes.Equal(old.Data, data) {
+			drift.Changed = append(drift.Changed, "data")
+		}
+		return drift
+	}
+	oldEntries, err := old.Entries()
+	if err != nil {
+		return drift
+	}
+	newEntries, err := ListArchive(data)
+	if err != nil {
+		return err
+	}
+	if old.Files != nil {
+		// per file accessors keep the content of regular files only
+		regular := newEntries[:0]
+		for _, e := range newEntries {
+			if e.Mode.IsRegular() {
+				e.Mode, e.ModTime = 0, time.Time{}
+				regular = append(regular, e)
+			}
+		}
+		newEntries = regular
+	}
+	prev := make(map[string]ArchiveEntry, len(oldEntries))
+	for _, e := range oldEntries {
+		prev[e.Name] = e
+	}
+	for _, e := range newEntries {
+		o, ok := prev[e.Name]
+		switch {
+		case !ok:
+			drift.Added = append(drift.Added, e.Name)
+		case o.Sum != e.Sum || o.Mode != e.Mode || !o.ModTime.Equal(e.ModTime):
+			drift.Changed = append(drift.Changed, e.Name)
+		}
+		delete(prev, e.Name)
+	}
+	for _, e := range oldEntries {
+		if _, ok := prev[e.Name]; ok {
+			drift.Removed = append(drift.Removed, e.Name)
+		}
+	}
+	return drift
+}
@@ -0,0 +1,162 @@
+//
+// Copyright 2020 Alexander Saastamoinen
+//
+//  Licensed under the EUPL, Version 1.2 or – as soon they
+// will be approved by the European Commission - subsequent
+// versions of the EUPL (the "Licence");
+//  You may not use this work except in compliance with the
+// Licence.
+//  You may obtain a copy of the Licence at:
+//
+//  https://joinup.ec.europa.eu/collection/eupl/eupl-text-eupl-12
+//
+//  Unless required by applicable law or agreed to in
+// writing, software distributed under the Licence is
+// distributed on an "AS IS" basis,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied.
+//  See the Licence for the specific language governing
+// permissions and limitations under the Licence.
+//
+
+package bindata
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// NoCompression is the compression level storing the data uncompressed, as
+// the zero level selects the codec's default.
+const NoCompression = -3
+
+// codec is a compression algorithm selectable with Maker.Compression.
+type codec struct {
+	pkg    string
+	writer func(w io.Writer, level int) (io.WriteCloser, error)
+	// reader is generated code opening r on the payload held in %[1]sPayload()
+	reader string
+	// open is the counterpart of reader used to read generated files back
+	open func(r io.Reader) (io.ReadCloser, error)
+}
+
+var codecs = map[string]codec{
+	"gzip": {
+		pkg: "compress/gzip",
+		writer: func(w io.Writer, level int) (io.WriteCloser, error) {
+			return gzip.NewWriterLevel(w, level)
+		},
+		open: func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		},
+		reader: `r, err := gzip.NewReader(bytes.NewReader(%[1]sPayload()))
+		if err != nil {
+			panic(err)
+		}`,
+	},
+	"zlib": {
+		pkg: "compress/zlib",
+		writer: func(w io.Writer, level int) (io.WriteCloser, error) {
+			return zlib.NewWriterLevel(w, level)
+		},
+		open: zlib.NewReader,
+		reader: `r, err := zlib.NewReader(bytes.NewReader(%[1]sPayload()))
+		if err != nil {
+			panic(err)
+		}`,
+	},
+	"flate": {
+		pkg: "compress/flate",
+		writer: func(w io.Writer, level int) (io.WriteCloser, error) {
+			return flate.NewWriter(w, level)
+		},
+		open: func(r io.Reader) (io.ReadCloser, error) {
+			return flate.NewReader(r), nil
+		},
+		reader: `r := flate.NewReader(bytes.NewReader(%[1]sPayload()))`,
+	},
+}
+
+const (
+	compressedReminder string = "//compressed with %s, decompressed once on first call"
+	payloadComment     string = "//%sPayload holds the compressed data returned by %s()"
+	// decompressTemplate declares the accessor over a compressed payload,
+	// %[2]s is the codec reader.
+	decompressTemplate string = `
+
+// %[1]sCompression is the algorithm %[1]sPayload() is compressed with.
+const %[1]sCompression = %[3]q
+
+var (
+	%[1]sOnce sync.Once
+	%[1]sData string
+)
+
+// %[1]sDecompressed returns the decompressed data, decompressing it on the
+// first call.
+func %[1]sDecompressed() string {
+	%[1]sOnce.Do(func() {
+		%[2]s
+		data, err := io.ReadAll(r)
+		if err != nil {
+			panic(err)
+		}
+		%[1]sData = string(data)
+	})
+	return %[1]sData
+}
+
+%[4]s
+func %[1]s() []byte {
+	return []byte(%[1]sDecompressed())
+}`
+)
+
+// codec returns the codec named by m.Compression, the zero codec if empty.
+func (m *Maker) codec() (codec, error) {
+	c, ok := codecs[m.Compression]
+	if !ok && m.Compression != "" {
+		return c, fmt.Errorf("unknown compression %q, expected gzip, zlib or flate", m.Compression)
+	}
+	return c, nil
+}
+
+// MakeCompressed compresses raw with the codec named by m.Compression at
+// m.Level, which ranges from -2 (flate.HuffmanOnly) to 9, with 0 and -1
+// selecting the codec's default and NoCompression storing the data
+// uncompressed.
+func (m *Maker) MakeCompressed(raw *bytes.Buffer) (*bytes.Buffer, error) {
+	c, err := m.codec()
+	if err != nil {
+		return nil, err
+	}
+	if c.writer == nil {
+		return nil, errors.New("no compression selected")
+	}
+	level := m.Level
+	switch {
+	case level == 0:
+		level = flate.DefaultCompression
+	case level == NoCompression:
+		level = flate.NoCompression
+	case level < flate.HuffmanOnly || level > flate.BestCompression:
+		return nil, fmt.Errorf("compression level %d out of range -2 to 9", m.Level)
+	}
+	buf := new(bytes.Buffer)
+	w, err := c.writer(buf, level)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", m.Compression, err)
+	}
+	if _, err := io.Copy(w, raw); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
@@ -0,0 +1,338 @@
+//
+// Copyright 2020 Alexander Saastamoinen
+//
+//  Licensed under the EUPL, Version 1.2 or – as soon they
+// will be approved by the European Commission - subsequent
+// versions of the EUPL (the "Licence");
+//  You may not use this work except in compliance with the
+// Licence.
+//  You may obtain a copy of the Licence at:
+//
+//  https://joinup.ec.europa.eu/collection/eupl/eupl-text-eupl-12
+//
+//  Unless required by applicable law or agreed to in
+// writing, software distributed under the Licence is
+// distributed on an "AS IS" basis,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied.
+//  See the Licence for the specific language governing
+// permissions and limitations under the Licence.
+//
+
+package bindata
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// optionKeys maps the json names of the Options fields to their index.
+var optionKeys = func() map[string]int {
+	keys := make(map[string]int)
+	t := reflect.TypeOf(Options{})
+	for i := 0; i < t.NumField(); i++ {
+		if key := t.Field(i).Tag.Get("json"); key != "-" {
+			keys[key] = i
+		}
+	}
+	return keys
+}()
+
+// ReadConfig reads the config file name, see ParseConfig. Relative paths
+// and directories of its targets are resolved against the directory of the
+// file, which is also the default Dir.
+func ReadConfig(name string) ([]Options, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	targets, err := ParseConfig(name, data)
+	if err != nil {
+		return nil, err
+	}
+	base := filepath.Dir(name)
+	resolve := func(paths []string) {
+		for i, p := range paths {
+			if !filepath.IsAbs(p) {
+				paths[i] = filepath.Join(base, p)
+			}
+		}
+	}
+	for i := range targets {
+		t := &targets[i]
+		if t.Dir == "" {
+			t.Dir = "."
+		}
+		dir := []string{t.Dir}
+		resolve(dir)
+		t.Dir = dir[0]
+		resolve(t.Paths)
+		for _, g := range t.Groups {
+			resolve(g.Paths)
+		}
+	}
+	return targets, nil
+}
+
+// ParseConfig parses the JSON config file data, named filename in errors,
+// listing the targets to generate:
+//
+//	{"targets": [
+//		{"name": "assets", "paths": ["static"], "recursive": true, "fs": true},
+//		{"name": "schema", "paths": ["schema.sql"], "output": "schema_data.go"}
+//	]}
+//
+// The keys of a target are the json names of the Options fields, level
+// defaults to -1 for the codec's default compression level. Unknown
+// keys, values of the wrong type and targets without paths or groups, with
+// an invalid name, encoding or compression or writing the same file as an
+// earlier target are reported as errors at their position in data, in the
+// file:line:column form of the Go tools.
+func ParseConfig(filename string, data []byte) ([]Options, error) {
+	p := &configParser{filename: filename, data: data, dec: json.NewDecoder(bytes.NewReader(data))}
+	if err := p.expect('{', "the config must be an object"); err != nil {
+		return nil, err
+	}
+	var targets []Options
+	found := false
+	for p.dec.More() {
+		off := p.dec.InputOffset()
+		key, err := p.key()
+		if err != nil {
+			return nil, err
+		}
+		if key != "targets" {
+			return nil, p.errorf(off, "unknown key %q, expected targets", key)
+		}
+		if found {
+			return nil, p.errorf(off, "targets given twice")
+		}
+		found = true
+		if targets, err = p.targets(); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expect('}', ""); err != nil {
+		return nil, err
+	}
+	if _, err := p.dec.Token(); err != io.EOF {
+		return nil, p.errorf(p.dec.InputOffset(), "data after the config object")
+	}
+	if len(targets) == 0 {
+		return nil, p.errorf(0, "no targets")
+	}
+	return targets, nil
+}
+
+// CheckOutputs reports targets writing the same Go file, as the targets of
+// a config file may after their options are overridden.
+func CheckOutputs(targets []Options) error {
+	seen := make(map[string]int)
+	for i, t := range targets {
+		for _, output := range t.outputs() {
+			if j, ok := seen[output]; ok && j != i {
+				return fmt.Errorf("target %d writes %s like target %d", i+1, output, j+1)
+			}
+			seen[output] = i
+		}
+	}
+	return nil
+}
+
+// outputs returns the Go files generated for opts.
+func (opts Options) outputs() []string {
+	if opts.GroupFiles && len(opts.Groups) > 0 {
+		names := make([]string, len(opts.Groups))
+		for i, g := range opts.Groups {
+			names[i] = filepath.Join(opts.Dir, g.Name+".go")
+		}
+		return names
+	}
+	name := opts.FileName
+	if name == "" {
+		name = opts.Name + ".go"
+		if opts.Name == "" {
+			name = "bindata.go"
+		}
+	}
+	return []string{filepath.Join(opts.Dir, name)}
+}
+
+// configParser reads a config file token by token to tell where its errors
+// are.
+type configParser struct {
+	filename string
+	data     []byte
+	dec      *json.Decoder
+}
+
+// errorf returns an error at the value or key following off.
+func (p *configParser) errorf(off int64, format string, args ...interface{}) error {
+	for off < int64(len(p.data)) && strings.IndexByte(" \t\r\n,:", p.data[off]) >= 0 {
+		off++
+	}
+	if off > int64(len(p.data)) {
+		off = int64(len(p.data))
+	}
+	line := 1 + bytes.Count(p.data[:off], []byte("\n"))
+	col := off - int64(bytes.LastIndexByte(p.data[:off], '\n'))
+	return fmt.Errorf("%s:%d:%d: %s", p.filename, line, col, fmt.Sprintf(format, args...))
+}
+
+// syntaxError turns an error of the decoder into one at its position.
+func (p *configParser) syntaxError(err error) error {
+	var syntax *json.SyntaxError
+	switch {
+	case errors.As(err, &syntax):
+		return p.errorf(syntax.Offset-1, "%s", strings.TrimPrefix(syntax.Error(), "json: "))
+	case err == io.EOF || errors.Is(err, io.ErrUnexpectedEOF):
+		return p.errorf(int64(len(p.data)), "unexpected end of the config")
+	}
+	return p.errorf(p.dec.InputOffset(), "%v", err)
+}
+
+// expect reads the delimiter delim, failing with msg if the next token is
+// something else.
+func (p *configParser) expect(delim json.Delim, msg string) error {
+	off := p.dec.InputOffset()
+	tok, err := p.dec.Token()
+	if err != nil {
+		return p.syntaxError(err)
+	}
+	if tok != delim {
+		if msg == "" {
+			msg = fmt.Sprintf("expected %s", delim)
+		}
+		return p.errorf(off, "%s", msg)
+	}
+	return nil
+}
+
+// key reads an object key.
+func (p *configParser) key() (string, error) {
+	tok, err := p.dec.Token()
+	if err != nil {
+		return "", p.syntaxError(err)
+	}
+	return tok.(string), nil
+}
+
+// targets reads the array of targets.
+func (p *configParser) targets() ([]Options, error) {
+	if err := p.expect('[', "targets must be an array of objects"); err != nil {
+		return nil, err
+	}
+	var targets []Options
+	outputs := make(map[string]int)
+	for p.dec.More() {
+		off := p.dec.InputOffset()
+		t, keys, err := p.target()
+		if err != nil {
+			return nil, err
+		}
+		at := func(key string) int64 {
+			if o, ok := keys[key]; ok {
+				return o
+			}
+			return off
+		}
+		_, known := codecs[t.Compression]
+		switch {
+		case len(t.Paths) == 0 && len(t.Groups) == 0:
+			return nil, p.errorf(off, "target has no paths or groups")
+		case t.Name != "" && !token.IsIdentifier(t.Name):
+			return nil, p.errorf(at("name"), "name %q is not a Go identifier", t.Name)
+		case t.Encoding != "" && t.Encoding != "bytes" && t.Encoding != "string" && t.Encoding != "asm":
+			return nil, p.errorf(at("encoding"), "unknown encoding %q, expected bytes, string or asm", t.Encoding)
+		case t.Compression != "" && !known:
+			return nil, p.errorf(at("compress"), "unknown compression %q, expected gzip, zlib or flate", t.Compression)
+		case t.Level < flate.HuffmanOnly || t.Level > flate.BestCompression:
+			return nil, p.errorf(at("level"), "compression level %d out of range -2 to 9", t.Level)
+		}
+		if _, ok := keys["level"]; ok && t.Level == flate.NoCompression {
+			t.Level = NoCompression // an explicit 0 stores uncompressed, like -level 0
+		}
+		for _, output := range t.outputs() {
+			if i, ok := outputs[output]; ok {
+				return nil, p.errorf(at("output"), "target writes %s like target %d", output, i+1)
+			}
+			outputs[output] = len(targets)
+		}
+		targets = append(targets, t)
+	}
+	return targets, p.expect(']', "")
+}
+
+// target reads a target, returning the offsets of its values by key.
+func (p *configParser) target() (Options, map[string]int64, error) {
+	var t Options
+	if err := p.expect('{', "a target must be an object"); err != nil {
+		return t, nil, err
+	}
+	v := reflect.ValueOf(&t).Elem()
+	keys := make(map[string]int64)
+	for p.dec.More() {
+		off := p.dec.InputOffset()
+		key, err := p.key()
+		if err != nil {
+			return t, nil, err
+		}
+		field, ok := optionKeys[key]
+		if !ok {
+			return t, nil, p.errorf(off, "unknown key %q", key)
+		}
+		if _, ok := keys[key]; ok {
+			return t, nil, p.errorf(off, "%s given twice", key)
+		}
+		var raw json.RawMessage
+		if err := p.dec.Decode(&raw); err != nil {
+			return t, nil, p.syntaxError(err)
+		}
+		valueOff := p.dec.InputOffset() - int64(len(raw))
+		keys[key] = valueOff
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.DisallowUnknownFields()
+		var typeErr *json.UnmarshalTypeError
+		switch err := dec.Decode(v.Field(field).Addr().Interface()); {
+		case errors.As(err, &typeErr):
+			start := valueStart(raw, typeErr.Offset)
+			return t, nil, p.errorf(valueOff+start, "%s: cannot use %s as %s", key, typeErr.Value, typeErr.Type)
+		case err != nil:
+			return t, nil, p.errorf(valueOff, "%s: %s", key, strings.TrimPrefix(err.Error(), "json: "))
+		}
+	}
+	return t, keys, p.expect('}', "")
+}
+
+// valueStart returns the offset of the string or literal of raw ending at
+// end, as reported by an UnmarshalTypeError, or 0 if it is none.
+func valueStart(raw []byte, end int64) int64 {
+	if end <= 0 || end > int64(len(raw)) {
+		return 0
+	}
+	if raw[end-1] == '"' {
+		for i := end - 2; i >= 0; i-- {
+			if raw[i] == '"' && (i == 0 || raw[i-1] != '\\') {
+				return i
+			}
+		}
+		return 0
+	}
+	i := end
+	for i > 0 && strings.IndexByte(" \t\r\n,:[]{}", raw[i-1]) < 0 {
+		i--
+	}
+	if i == end {
+		return 0
+	}
+	return i
+}
@@ -0,0 +1,93 @@
+//
+// Copyright 2020 Alexander Saastamoinen
+//
+//  Licensed under the EUPL, Version 1.2 or – as soon they
+// will be approved by the European Commission - subsequent
+// versions of the EUPL (the "Licence");
+//  You may not use this work except in compliance with the
+// Licence.
+//  You may obtain a copy of the Licence at:
+//
+//  https://joinup.ec.europa.eu/collection/eupl/eupl-text-eupl-12
+//
+//  Unless required by applicable law or agreed to in
+// writing, software distributed under the Licence is
+// distributed on an "AS IS" basis,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied.
+//  See the Licence for the specific language governing
+// permissions and limitations under the Licence.
+//
+
+package bindata
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+const (
+	// constsDoc documents the naming scheme of constsTemplate in the file
+	// header.
+	constsDoc string = `//
+//%[1]sAsset constants name every archived file after its path: the path is
+//split into words at everything but letters and digits, which is dropped,
+//each word is capitalised, or upper cased if a common initialism like CSS,
+//JS or HTML, and the words are joined behind %[1]sAsset, so
+//static/css/main.css becomes %[1]sAssetStaticCSSMainCSS. Colliding names
+//get a number appended, starting at 2, in path order.
+`
+	constsTemplate string = `
+
+// %[1]sAsset is the archive path of an embedded file.
+type %[1]sAsset string
+
+// The archive paths of the embedded files, named as described in the file
+// header.
+const (
+`
+)
+
+// writeConsts writes a typed constant holding the archive path of every
+// regular file of the list from OpenFiles archived by MakeTar, sorted by
+// path. In development mode files keep their own names.
+func (m *Maker) writeConsts(buf *bytes.Buffer, funcName string, dev bool) error {
+	names, idents := m.constIdents(funcName)
+	if _, err := fmt.Fprintf(buf, constsTemplate, funcName); err != nil {
+		return err
+	}
+	for i, name := range names {
+		value := name
+		if hashed, ok := m.manifest[name]; ok && !dev {
+			value = hashed
+		}
+		fmt.Fprintf(buf, "\t%s %sAsset = %q\n", idents[i], funcName, value)
+	}
+	buf.WriteString(")\n")
+	return nil
+}
+
+// constIdents returns the sorted paths writeConsts declares constants for
+// and their identifiers.
+func (m *Maker) constIdents(funcName string) ([]string, []string) {
+	names := append([]string(nil), m.paths...)
+	sort.Strings(names)
+	return names, naming{prefix: funcName + "Asset", initialisms: true, suffixes: []string{""}}.idents(names)
+}
+
+// headerComments returns the comments of the file header following the
+// autogenerated line.
+func (m *Maker) headerComments(funcName string) string {
+	var s string
+	if m.inputHash != "" {
+		s += fmt.Sprintf(hashComment, m.inputHash)
+	}
+	if m.PerFile {
+		s += fmt.Sprintf(perFileComment, funcName)
+	}
+	if m.Consts {
+		s += fmt.Sprintf(constsDoc, funcName)
+	}
+	return s
+}
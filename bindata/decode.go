@@ -0,0 +1,399 @@
+//
+// Copyright 2020 Alexander Saastamoinen
+//
+//  Licensed under the EUPL, Version 1.2 or – as soon they
+// will be approved by the European Commission - subsequent
+// versions of the EUPL (the "Licence");
+//  You may not use this work except in compliance with the
+// Licence.
+//  You may obtain a copy of the Licence at:
+//
+//  https://joinup.ec.europa.eu/collection/eupl/eupl-text-eupl-12
+//
+//  Unless required by applicable law or agreed to in
+// writing, software distributed under the Licence is
+// distributed on an "AS IS" basis,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied.
+//  See the Licence for the specific language governing
+// permissions and limitations under the Licence.
+//
+
+package bindata
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/fs"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Payload is the data held by a generated file, as read back by ReadSource.
+type Payload struct {
+	Name        string // generated function name
+	Encoding    string // bytes, string or asm
+	Compression string // empty if stored uncompressed
+	Archived    bool   // Data is a tar archive rather than a single file
+	Stored      []byte // data as stored, before decompression
+	Data        []byte // data returned by the generated function
+	// Files holds the content of every file by archive path for per file
+	// accessors, see Options.PerFile, which store no single data. Stored
+	// and Data are nil then.
+	Files map[string][]byte
+}
+
+// Entries returns the entries of the archive p holds in archive order, or
+// the files of per file accessors sorted by path, with only their name,
+// size and sum set.
+func (p *Payload) Entries() ([]ArchiveEntry, error) {
+	if p.Files == nil {
+		return ListArchive(p.Data)
+	}
+	names := make([]string, 0, len(p.Files))
+	for name := range p.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	entries := make([]ArchiveEntry, len(names))
+	for i, name := range names {
+		entries[i] = ArchiveEntry{Name: name, Size: int64(len(p.Files[name])), Sum: sha256.Sum256(p.Files[name])}
+	}
+	return entries, nil
+}
+
+// ReadSource parses the Go file filename generated by embed and rebuilds the
+// data of the function name. For the asm encoding the accompanying assembly
+// file is read as well, for per file accessors the files are read into
+// Payload.Files.
+func ReadSource(filename, name string) (*Payload, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, nil, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	p := &Payload{Name: name}
+	for _, d := range file.Decls {
+		if f, ok := d.(*ast.FuncDecl); ok && f.Name.Name == name && f.Doc != nil {
+			for _, c := range f.Doc.List {
+				p.Archived = p.Archived || c.Text == tarReminder
+			}
+		}
+	}
+	decls := topLevel(file)
+	if prefixes := perFileNames(file); slices.Contains(prefixes, name) {
+		p.Encoding = "string"
+		if p.Files, err = perFileValues(file, decls, name, prefixes); err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", filename, name, err)
+		}
+		return p, nil
+	}
+	dataName := name
+	if v, ok := decls[name+"Compression"].(*ast.BasicLit); ok {
+		if p.Compression, err = strconv.Unquote(v.Value); err != nil {
+			return nil, fmt.Errorf("%s: %sCompression: %w", filename, name, err)
+		}
+		dataName += "Payload"
+	}
+
+	switch {
+	case decls[dataName+"Literal"] != nil:
+		p.Encoding = "string"
+		p.Stored, err = stringValue(decls[dataName+"Literal"])
+	case decls[dataName+"Asm"] != nil:
+		p.Encoding = "asm"
+		p.Stored, err = asmValue(decls[dataName+"Asm"], strings.TrimSuffix(filename, ".go")+".s", dataName+"Asm")
+	case decls[dataName] != nil:
+		p.Encoding = "bytes"
+		p.Stored, err = bytesValue(decls[dataName])
+	default:
+		return nil, fmt.Errorf("%s: no data found for %s", filename, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s: %w", filename, name, err)
+	}
+
+	p.Data = p.Stored
+	if p.Compression != "" {
+		c, ok := codecs[p.Compression]
+		if !ok {
+			return nil, fmt.Errorf("%s: unknown compression %q", filename, p.Compression)
+		}
+		r, err := c.open(bytes.NewReader(p.Stored))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", filename, p.Compression, err)
+		}
+		defer r.Close()
+		if p.Data, err = io.ReadAll(r); err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", filename, p.Compression, err)
+		}
+	}
+	return p, nil
+}
+
+// SourceNames returns the names of the functions of the Go file filename
+// generated by embed whose data ReadSource rebuilds, in file order, names
+// given to per file accessors first. A file holds more than one with
+// Options.Groups.
+func SourceNames(filename string) ([]string, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), filename, nil, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	decls := topLevel(file)
+	names := perFileNames(file)
+	for _, d := range file.Decls {
+		f, ok := d.(*ast.FuncDecl)
+		if !ok || f.Recv != nil || f.Body == nil || f.Type.Params.NumFields() != 0 || f.Type.Results.NumFields() != 1 {
+			continue
+		}
+		if t, ok := f.Type.Results.List[0].Type.(*ast.ArrayType); !ok || t.Len != nil || fmt.Sprint(t.Elt) != "byte" {
+			continue
+		}
+		name := f.Name.Name
+		switch {
+		case strings.HasSuffix(name, "Payload") && decls[strings.TrimSuffix(name, "Payload")+"Compression"] != nil:
+			// the compressed data of another function
+			continue
+		case decls[name+"Compression"] != nil, decls[name+"Literal"] != nil, decls[name+"Asm"] != nil:
+		default:
+			// the bytes encoding declares the data in the function
+			if _, err := bytesValue(f.Body); err != nil {
+				continue
+			}
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// perFileNames returns the names recorded by perFileComment in file.
+func perFileNames(file *ast.File) []string {
+	var names []string
+	marker := strings.TrimSuffix(perFileComment, "%s\n")
+	for _, g := range file.Comments {
+		for _, c := range g.List {
+			if name, ok := strings.CutPrefix(c.Text, marker); ok {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// perFileValues returns the content of the per file accessors of name by
+// path, recovered from their doc comment. Accessors of a longer name among
+// prefixes belong to that.
+func perFileValues(file *ast.File, decls map[string]ast.Node, name string, prefixes []string) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	for _, d := range file.Decls {
+		f, ok := d.(*ast.FuncDecl)
+		if !ok || f.Recv != nil || f.Doc == nil || decls[f.Name.Name+"Data"] == nil {
+			continue
+		}
+		ident := f.Name.Name
+		owner := ""
+		for _, p := range prefixes {
+			if strings.HasPrefix(ident, p) && len(p) > len(owner) {
+				owner = p
+			}
+		}
+		path, ok := strings.CutPrefix(f.Doc.List[0].Text, "// "+ident+" returns a copy of ")
+		if owner != name || !ok {
+			continue
+		}
+		path = strings.TrimSuffix(path, ".")
+		if unquoted, err := strconv.Unquote(path); err == nil && strings.HasPrefix(path, `"`) {
+			path = unquoted
+		}
+		data, err := stringValue(decls[ident+"Data"])
+		if err != nil {
+			return nil, fmt.Errorf("%sData: %w", ident, err)
+		}
+		files[path] = data
+	}
+	return files, nil
+}
+
+// topLevel maps the names of the top level declarations of file to their
+// value for constants, their type for variables and their body for
+// functions.
+func topLevel(file *ast.File) map[string]ast.Node {
+	decls := make(map[string]ast.Node)
+	for _, d := range file.Decls {
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			if d.Recv == nil && d.Body != nil {
+				decls[d.Name.Name] = d.Body
+			}
+		case *ast.GenDecl:
+			for _, s := range d.Specs {
+				v, ok := s.(*ast.ValueSpec)
+				if !ok {
+					continue
+				}
+				for i, n := range v.Names {
+					switch {
+					case d.Tok == token.CONST && i < len(v.Values):
+						decls[n.Name] = v.Values[i]
+					case d.Tok == token.VAR && v.Type != nil:
+						decls[n.Name] = v.Type
+					}
+				}
+			}
+		}
+	}
+	return decls
+}
+
+// bytesValue returns the data of a bytes encoded function body.
+func bytesValue(body ast.Node) ([]byte, error) {
+	var lit *ast.CompositeLit
+	ast.Inspect(body, func(n ast.Node) bool {
+		if c, ok := n.(*ast.CompositeLit); ok && lit == nil {
+			lit = c
+		}
+		return lit == nil
+	})
+	if lit == nil {
+		return nil, errors.New("no []byte literal")
+	}
+	data := make([]byte, 0, len(lit.Elts))
+	for _, e := range lit.Elts {
+		b, ok := e.(*ast.BasicLit)
+		if !ok || b.Kind != token.INT {
+			return nil, fmt.Errorf("unexpected element %T in []byte literal", e)
+		}
+		v, err := strconv.ParseUint(b.Value, 0, 8)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, byte(v))
+	}
+	return data, nil
+}
+
+// stringValue returns the data of a string encoded constant, a possibly
+// parenthesised sum of string literals.
+func stringValue(expr ast.Node) ([]byte, error) {
+	var data []byte
+	var walk func(e ast.Node) error
+	walk = func(e ast.Node) error {
+		switch e := e.(type) {
+		case *ast.ParenExpr:
+			return walk(e.X)
+		case *ast.BinaryExpr:
+			if e.Op != token.ADD {
+				return fmt.Errorf("unexpected operator %s in string literal", e.Op)
+			}
+			if err := walk(e.X); err != nil {
+				return err
+			}
+			return walk(e.Y)
+		case *ast.BasicLit:
+			s, err := strconv.Unquote(e.Value)
+			if err != nil {
+				return err
+			}
+			data = append(data, s...)
+			return nil
+		}
+		return fmt.Errorf("unexpected %T in string literal", e)
+	}
+	err := walk(expr)
+	return data, err
+}
+
+// asmValue returns the data of sym defined by DATA directives in the
+// assembly file asmName, typ is the array type of its Go declaration.
+func asmValue(typ ast.Node, asmName, sym string) ([]byte, error) {
+	arr, ok := typ.(*ast.ArrayType)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an array", sym)
+	}
+	size, ok := arr.Len.(*ast.BasicLit)
+	if !ok {
+		return nil, fmt.Errorf("%s has no constant length", sym)
+	}
+	n, err := strconv.Atoi(size.Value)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, n)
+	f, err := os.Open(asmName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	prefix := "DATA ·" + sym + "+"
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if !strings.HasPrefix(text, prefix) {
+			continue
+		}
+		off, rest, ok := strings.Cut(text[len(prefix):], "(SB)/")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: malformed DATA directive", asmName, line)
+		}
+		_, value, ok := strings.Cut(rest, ", $")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: malformed DATA directive", asmName, line)
+		}
+		start, err := strconv.Atoi(off)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", asmName, line, err)
+		}
+		chunk, err := strconv.Unquote(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", asmName, line, err)
+		}
+		if start < 0 || start+len(chunk) > n {
+			return nil, fmt.Errorf("%s:%d: DATA outside of %s", asmName, line, sym)
+		}
+		copy(data[start:], chunk)
+	}
+	return data, scanner.Err()
+}
+
+// ArchiveEntry describes a file of a generated tar archive.
+type ArchiveEntry struct {
+	Name    string // slash separated, directories end in /
+	Size    int64
+	Mode    fs.FileMode
+	ModTime time.Time
+	Sum     [sha256.Size]byte // of the content
+}
+
+// ListArchive returns the entries of the tar archive data in archive order.
+func ListArchive(data []byte) ([]ArchiveEntry, error) {
+	var entries []ArchiveEntry
+	r := tar.NewReader(bytes.NewReader(data))
+	for {
+		h, err := r.Next()
+		if err == io.EOF {
+			return entries, nil
+		} else if err != nil {
+			return nil, err
+		}
+		hash := sha256.New()
+		if _, err := io.Copy(hash, r); err != nil {
+			return nil, err
+		}
+		e := ArchiveEntry{Name: h.Name, Size: h.Size, Mode: h.FileInfo().Mode(), ModTime: h.ModTime}
+		hash.Sum(e.Sum[:0])
+		entries = append(entries, e)
+	}
+}
@@ -0,0 +1,202 @@
+//
+// Copyright 2020 Alexander Saastamoinen
+//
+//  Licensed under the EUPL, Version 1.2 or – as soon they
+// will be approved by the European Commission - subsequent
+// versions of the EUPL (the "Licence");
+//  You may not use this work except in compliance with the
+// Licence.
+//  You may obtain a copy of the Licence at:
+//
+//  https://joinup.ec.europa.eu/collection/eupl/eupl-text-eupl-12
+//
+//  Unless required by applicable law or agreed to in
+// writing, software distributed under the Licence is
+// distributed on an "AS IS" basis,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied.
+//  See the Licence for the specific language governing
+// permissions and limitations under the Licence.
+//
+
+package bindata
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	// DevTag is the build tag selecting the file generated by MakeDev.
+	DevTag string = "embed_dev"
+	// importPath is the import path of this package, used by the
+	// development file.
+	importPath string = "github.com/miscing/embed/bindata"
+
+	embeddedConstraint string = "//go:build !" + DevTag + "\n\n"
+	devTemplate        string = `//go:build ` + DevTag + `
+
+package %[2]s
+
+//autogenerated by embed
+//development mode: reads the embedded paths from disk on every call, build
+//without the ` + DevTag + ` tag for the embedded data
+%[7]s%[3]s
+// %[1]sPaths are the embedded paths, relative to the directory of this file.
+var %[1]sPaths = %[4]s
+
+// %[1]sMaker walks %[1]sPaths with the options the embedded data was
+// generated with.
+var %[1]sMaker = embedbindata.Maker{%[5]s}
+
+%[6]s
+func %[1]s() []byte {
+	_, file, _, ok := runtime.Caller(0)
+	if !ok {
+		panic("` + DevTag + `: cannot locate the directory of the generated file")
+	}
+	paths := make([]string, len(%[1]sPaths))
+	for i, p := range %[1]sPaths {
+		paths[i] = filepath.FromSlash(p)
+		if !filepath.IsAbs(paths[i]) {
+			paths[i] = filepath.Join(filepath.Dir(file), paths[i])
+		}
+	}
+	m := %[1]sMaker
+	files, err := m.OpenFiles(paths)
+	if err != nil {
+		panic(err)
+	}
+	data, err := m.MakeTar(files)
+	if err != nil {
+		panic(err)
+	}
+	return data.Bytes()
+}`
+
+	devCompressionTemplate string = `
+
+// %[1]sCompression is the algorithm the embedded data is compressed with,
+// the files read in development mode are not.
+const %[1]sCompression = %[2]q`
+
+	devZeroCopyTemplate string = `
+
+// %[1]sString returns the data read from disk as a string.
+func %[1]sString() string {
+	return string(%[1]s())
+}
+
+// %[1]sReader returns a reader over the data read from disk.
+func %[1]sReader() *strings.Reader {
+	return strings.NewReader(%[1]sString())
+}`
+)
+
+// MakeDev returns the Go source of a file declaring the same accessors as
+// MakeSource, reading paths from disk on every call instead of embedding
+// them. Relative paths are resolved against the directory of the generated
+// file. It is built with the embed_dev tag, set m.Dev to exclude the
+// embedded files from such builds.
+func (m *Maker) MakeDev(packageName string, funcName string, paths []string) (*bytes.Buffer, error) {
+	if _, err := m.codec(); err != nil {
+		return nil, err
+	}
+	imports := []string{"path/filepath", "runtime", "embedbindata " + importPath}
+	if m.FS {
+		imports = append(imports, fsImports...)
+	}
+	if m.ZeroCopy {
+		imports = append(imports, "strings")
+	}
+	if m.HTTP {
+		imports = append(imports, httpImports...)
+	}
+	if len(m.Fingerprint) > 0 || m.SRI {
+		imports = append(imports, "strings")
+	}
+
+	var fields []string
+	for _, f := range []struct {
+		name string
+		set  bool
+	}{
+		{"SkipDir", m.SkipDir}, {"ParseHidden", m.ParseHidden}, {"Recurssive", m.Recurssive},
+		{"KeepRoot", m.KeepRoot}, {"GitIgnore", m.GitIgnore}, {"Reproducible", m.Reproducible},
+		// archive even a single file like MakeTar did for the embedded data,
+		// fingerprinted names are left out on purpose
+		{"FS", m.FS}, {"PerFile", m.PerFile}, {"Consts", m.Consts},
+	} {
+		if f.set {
+			fields = append(fields, f.name+": true")
+		}
+	}
+	if len(m.Include) > 0 {
+		fields = append(fields, fmt.Sprintf("Include: %#v", m.Include))
+	}
+	if len(m.Exclude) > 0 {
+		fields = append(fields, fmt.Sprintf("Exclude: %#v", m.Exclude))
+	}
+	comment := ""
+	if m.isTar {
+		comment = tarReminder
+	}
+	doc := ""
+	if m.Consts {
+		doc = fmt.Sprintf(constsDoc, funcName)
+	}
+
+	buf := new(bytes.Buffer)
+	_, err := fmt.Fprintf(buf, devTemplate, funcName, packageName, importBlock(imports),
+		fmt.Sprintf("%#v", paths), strings.Join(fields, ", "), comment, doc)
+	if err != nil {
+		return nil, err
+	}
+	if m.Consts {
+		if err = m.writeConsts(buf, funcName, true); err != nil {
+			return nil, err
+		}
+	}
+	if m.Compression != "" {
+		if _, err = fmt.Fprintf(buf, devCompressionTemplate, funcName, m.Compression); err != nil {
+			return nil, err
+		}
+	}
+	if m.ZeroCopy {
+		if _, err = fmt.Fprintf(buf, devZeroCopyTemplate, funcName); err != nil {
+			return nil, err
+		}
+	}
+	if m.FS {
+		snapshot := fmt.Sprintf(fsDevSnapshotTemplate, funcName)
+		if _, err = fmt.Fprintf(buf, fsTemplate, funcName, snapshot); err != nil {
+			return nil, err
+		}
+	}
+	if len(m.Fingerprint) > 0 || m.HTTP {
+		// files are read under their own names
+		if _, err = fmt.Fprintf(buf, manifestTemplate, funcName, "map[string]string{}"); err != nil {
+			return nil, err
+		}
+	}
+	if m.SRI {
+		// digests of changing files are useless
+		if _, err = fmt.Fprintf(buf, sriTemplate, funcName, "map[string]"+funcName+"Digest{}"); err != nil {
+			return nil, err
+		}
+	}
+	if m.HTTP {
+		// the files change under the handler, leave ETags to Last-Modified
+		doc, prefixes := m.fallback()
+		if _, err = fmt.Fprintf(buf, httpTemplate, funcName, "map[string]string{}", doc, prefixes); err != nil {
+			return nil, err
+		}
+		dev := *m
+		dev.Precompress = false
+		if err = dev.writeGzipped(buf, funcName, nil); err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
@@ -0,0 +1,210 @@
+//
+// Copyright 2020 Alexander Saastamoinen
+//
+//  Licensed under the EUPL, Version 1.2 or – as soon they
+// will be approved by the European Commission - subsequent
+// versions of the EUPL (the "Licence");
+//  You may not use this work except in compliance with the
+// Licence.
+//  You may obtain a copy of the Licence at:
+//
+//  https://joinup.ec.europa.eu/collection/eupl/eupl-text-eupl-12
+//
+//  Unless required by applicable law or agreed to in
+// writing, software distributed under the Licence is
+// distributed on an "AS IS" basis,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied.
+//  See the Licence for the specific language governing
+// permissions and limitations under the Licence.
+//
+
+package bindata
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+)
+
+const (
+	// literalWidth is the number of data bytes per line of a string literal.
+	literalWidth int = 96
+
+	stringTemplate string = `
+%[1]s
+func %[2]s() []byte {
+	return []byte(%[2]sLiteral)
+}
+
+// %[2]sLiteral holds the data returned by %[2]s().
+const %[2]sLiteral = `
+
+	asmStubTemplate string = `
+%[1]s
+func %[2]s() []byte {
+	return append([]byte(nil), %[2]sAsm[:]...)
+}
+
+// %[2]sAsm holds the data returned by %[2]s(), it is defined in the
+// accompanying assembly file.
+var %[2]sAsm [%[3]d]byte
+`
+	asmTemplate string = `//autogenerated by embed
+
+#include "textflag.h"
+
+`
+	// asmWidth is the number of data bytes per DATA directive, the most the
+	// assembler accepts.
+	asmWidth int = 8
+)
+
+// byteLiterals holds the source form of every byte value in the bytes
+// encoding.
+var byteLiterals [256]string
+
+func init() {
+	for i := range byteLiterals {
+		byteLiterals[i] = fmt.Sprintf("%#v, ", byte(i))
+	}
+}
+
+// writeData writes a function called name returning raw, encoded as selected
+// by m.Encoding.
+func (m *Maker) writeData(buf *bytes.Buffer, comment string, name string, raw []byte) error {
+	switch m.Encoding {
+	case "", "bytes":
+		if _, err := fmt.Fprintf(buf, dataTemplate, comment, name); err != nil {
+			return err
+		}
+		for _, b := range raw {
+			buf.WriteString(byteLiterals[b])
+		}
+		_, err := fmt.Fprint(buf, postTemplate)
+		return err
+	case "string":
+		if _, err := fmt.Fprintf(buf, stringTemplate, comment, name); err != nil {
+			return err
+		}
+		writeStringLiteral(buf, raw)
+		return nil
+	case "asm":
+		_, err := fmt.Fprintf(buf, asmStubTemplate, comment, name, len(raw))
+		return err
+	}
+	return fmt.Errorf("unknown encoding %q, expected bytes, string or asm", m.Encoding)
+}
+
+// MakeAsm returns the assembly file defining the data of rawBuf for the asm
+// encoding, MakeSource writes the Go declarations using it.
+func (m *Maker) MakeAsm(rawBuf *bytes.Buffer, funcName string) (*bytes.Buffer, error) {
+	raw := rawBuf.Bytes()
+	sym := "\u00b7" + m.dataName(funcName) + "Asm"
+	buf := new(bytes.Buffer)
+	buf.Grow(len(raw) * 6)
+	if m.Dev {
+		buf.WriteString(embeddedConstraint)
+	}
+	buf.WriteString(asmTemplate)
+	if len(raw) == 0 {
+		// a zero sized GLOBL clashes with the Go declaration
+		return buf, nil
+	}
+	var scratch []byte
+	for off := 0; off < len(raw); off += asmWidth {
+		chunk := raw[off:]
+		if len(chunk) > asmWidth {
+			chunk = chunk[:asmWidth]
+		}
+		scratch = append(scratch[:0], "DATA "...)
+		scratch = append(scratch, sym...)
+		scratch = append(scratch, '+')
+		scratch = strconv.AppendInt(scratch, int64(off), 10)
+		scratch = append(scratch, "(SB)/"...)
+		scratch = strconv.AppendInt(scratch, int64(len(chunk)), 10)
+		scratch = append(scratch, ", $\""...)
+		for _, b := range chunk {
+			switch {
+			case b == '"' || b == '\\':
+				scratch = append(scratch, '\\', b)
+			case b >= 0x20 && b < 0x7f:
+				scratch = append(scratch, b)
+			default:
+				scratch = append(scratch, '\\', 'x', hexDigits[b>>4], hexDigits[b&0xf])
+			}
+		}
+		scratch = append(scratch, "\"\n"...)
+		buf.Write(scratch)
+	}
+	_, err := fmt.Fprintf(buf, "GLOBL %s(SB), RODATA|NOPTR, $%d\n", sym, len(raw))
+	return buf, err
+}
+
+// writeStringLiteral writes raw as a sum of interpreted string literals of
+// at most literalWidth data bytes each. Printable ASCII and valid UTF-8 is
+// written as is, everything else escaped. The sum is parenthesised as a
+// balanced tree, a flat chain of + takes the compiler quadratic time to fold.
+func writeStringLiteral(buf *bytes.Buffer, raw []byte) {
+	line := new(bytes.Buffer)
+	line.Grow(len(raw))
+	var ends []int
+	width := 0
+	for len(raw) > 0 {
+		r, size := utf8.DecodeRune(raw)
+		if width+size > literalWidth {
+			ends = append(ends, line.Len())
+			width = 0
+		}
+		switch {
+		case r == '"' || r == '\\':
+			line.WriteByte('\\')
+			line.WriteByte(raw[0])
+		case r == '\n':
+			line.WriteString(`\n`)
+		case r == '\t':
+			line.WriteString(`\t`)
+		case r == '\r':
+			line.WriteString(`\r`)
+		case r >= 0x20 && r < 0x7f:
+			line.WriteByte(raw[0])
+		case r >= utf8.RuneSelf && r != utf8.RuneError && r != '\uFEFF':
+			// the source must not hold a byte order mark outside its start
+			line.Write(raw[:size])
+		default:
+			size = 1
+			line.WriteString(`\x`)
+			line.WriteByte(hexDigits[raw[0]>>4])
+			line.WriteByte(hexDigits[raw[0]&0xf])
+		}
+		width += size
+		raw = raw[size:]
+	}
+	ends = append(ends, line.Len())
+	lines := make([][]byte, len(ends))
+	start := 0
+	for i, end := range ends {
+		lines[i] = line.Bytes()[start:end]
+		start = end
+	}
+	writeBalanced(buf, lines)
+}
+
+// writeBalanced writes lines joined by + with parentheses around each half.
+func writeBalanced(buf *bytes.Buffer, lines [][]byte) {
+	if len(lines) == 1 {
+		buf.WriteByte('"')
+		buf.Write(lines[0])
+		buf.WriteByte('"')
+		return
+	}
+	half := len(lines) / 2
+	buf.WriteByte('(')
+	writeBalanced(buf, lines[:half])
+	buf.WriteString(" +\n\t")
+	writeBalanced(buf, lines[half:])
+	buf.WriteByte(')')
+}
+
+const hexDigits string = "0123456789abcdef"
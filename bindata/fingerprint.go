@@ -0,0 +1,94 @@
+//
+// Copyright 2020 Alexander Saastamoinen
+//
+//  Licensed under the EUPL, Version 1.2 or – as soon they
+// will be approved by the European Commission - subsequent
+// versions of the EUPL (the "Licence");
+//  You may not use this work except in compliance with the
+// Licence.
+//  You may obtain a copy of the Licence at:
+//
+//  https://joinup.ec.europa.eu/collection/eupl/eupl-text-eupl-12
+//
+//  Unless required by applicable law or agreed to in
+// writing, software distributed under the Licence is
+// distributed on an "AS IS" basis,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied.
+//  See the Licence for the specific language governing
+// permissions and limitations under the Licence.
+//
+
+package bindata
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+const (
+	// fingerprintLen is the number of hex digits of the content hash in
+	// fingerprinted names.
+	fingerprintLen int = 10
+
+	// manifestTemplate declares the manifest of fingerprinted names, %[2]s is
+	// its literal.
+	manifestTemplate string = `
+
+// %[1]sManifest maps the paths of fingerprinted files to the content hashed
+// names they are stored under.
+var %[1]sManifest = %[2]s
+
+// %[1]sImmutable holds the fingerprinted names of %[1]sManifest, their
+// content never changes.
+var %[1]sImmutable = func() map[string]bool {
+	m := make(map[string]bool, len(%[1]sManifest))
+	for _, hashed := range %[1]sManifest {
+		m[hashed] = true
+	}
+	return m
+}()
+
+// %[1]sAssetURL returns the fingerprinted name of the file at p, which may
+// start with a slash, or p itself if it is not fingerprinted.
+func %[1]sAssetURL(p string) string {
+	name := strings.TrimPrefix(p, "/")
+	if hashed, ok := %[1]sManifest[name]; ok {
+		return p[:len(p)-len(name)] + hashed
+	}
+	return p
+}`
+)
+
+// fingerprint renames the regular file f to carry a hash of its content
+// before its extension, as in app.3f9a1c2b7d.js, if it matches one of
+// m.Fingerprint. The rename is recorded in m.manifest.
+func (m *Maker) fingerprint(f *Entry, head *tar.Header) error {
+	if head.Typeflag != tar.TypeReg {
+		return nil
+	}
+	if ok, err := matchAny(m.Fingerprint, f.Name); err != nil || !ok {
+		return err
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, f.File); err != nil {
+		return fmt.Errorf("fingerprinting %s: %w", f.File.Name(), err)
+	}
+	if _, err := f.File.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	sum := hex.EncodeToString(h.Sum(nil))[:fingerprintLen]
+	ext := path.Ext(f.Name)
+	if ext == path.Base(f.Name) {
+		// a dot file, the name is all extension
+		ext = ""
+	}
+	head.Name = strings.TrimSuffix(f.Name, ext) + "." + sum + ext
+	m.manifest[f.Name] = head.Name
+	return nil
+}
@@ -0,0 +1,229 @@
+//
+// Copyright 2020 Alexander Saastamoinen
+//
+//  Licensed under the EUPL, Version 1.2 or – as soon they
+// will be approved by the European Commission - subsequent
+// versions of the EUPL (the "Licence");
+//  You may not use this work except in compliance with the
+// Licence.
+//  You may obtain a copy of the Licence at:
+//
+//  https://joinup.ec.europa.eu/collection/eupl/eupl-text-eupl-12
+//
+//  Unless required by applicable law or agreed to in
+// writing, software distributed under the Licence is
+// distributed on an "AS IS" basis,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied.
+//  See the Licence for the specific language governing
+// permissions and limitations under the Licence.
+//
+
+package bindata
+
+// fsImports are the packages used by fsTemplate.
+var fsImports = []string{"archive/tar", "bytes", "errors", "io", "io/fs", "path", "sort", "sync", "time"}
+
+// fsTemplate is appended to the generated source when Maker.FS is set. It
+// declares %[1]sFS, a read-only file system over the tar archive returned
+// by %[1]s(). %[2]s is one of the snapshot templates below.
+const fsTemplate string = `
+
+// %[1]sFS is a read-only file system holding the files archived in %[1]s().
+var %[1]sFS = new(%[1]sFileSystem)
+
+// %[1]sFileSystem implements fs.FS, fs.ReadDirFS, fs.ReadFileFS, fs.StatFS
+// and fs.GlobFS over the archive returned by %[1]s().
+type %[1]sFileSystem struct {
+	once  sync.Once
+	err   error
+	files map[string]*%[1]sFile
+}
+
+// %[1]sFile is a file or directory of %[1]sFS, it implements fs.FileInfo and
+// fs.DirEntry.
+type %[1]sFile struct {
+	name     string
+	mode     fs.FileMode
+	size     int64
+	modTime  time.Time
+	data     []byte
+	children []*%[1]sFile
+}
+
+func (f *%[1]sFile) Name() string               { return path.Base(f.name) }
+func (f *%[1]sFile) Size() int64                { return f.size }
+func (f *%[1]sFile) Mode() fs.FileMode          { return f.mode }
+func (f *%[1]sFile) ModTime() time.Time         { return f.modTime }
+func (f *%[1]sFile) IsDir() bool                { return f.mode.IsDir() }
+func (f *%[1]sFile) Sys() interface{}           { return nil }
+func (f *%[1]sFile) Type() fs.FileMode          { return f.mode.Type() }
+func (f *%[1]sFile) Info() (fs.FileInfo, error) { return f, nil }
+
+func (fsys *%[1]sFileSystem) load() {
+	fsys.files = map[string]*%[1]sFile{".": {name: ".", mode: fs.ModeDir | 0555}}
+	r := tar.NewReader(bytes.NewReader(%[1]s()))
+	for {
+		h, err := r.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			fsys.err = err
+			return
+		}
+		name := path.Clean(h.Name)
+		if !fs.ValidPath(name) || name == "." {
+			continue
+		}
+		f := &%[1]sFile{name: name, mode: h.FileInfo().Mode(), size: h.Size, modTime: h.ModTime}
+		if f.mode.IsRegular() {
+			if f.data, err = io.ReadAll(r); err != nil {
+				fsys.err = err
+				return
+			}
+		}
+		fsys.files[name] = f
+	}
+	names := make([]string, 0, len(fsys.files))
+	for name := range fsys.files {
+		names = append(names, name)
+	}
+	for _, name := range names {
+		fsys.link(fsys.files[name])
+	}
+	for _, f := range fsys.files {
+		sort.Slice(f.children, func(i, j int) bool { return f.children[i].name < f.children[j].name })
+	}
+}
+
+// link adds f to its parent directory, creating parents missing from the archive.
+func (fsys *%[1]sFileSystem) link(f *%[1]sFile) {
+	if f.name == "." {
+		return
+	}
+	dir := path.Dir(f.name)
+	parent, ok := fsys.files[dir]
+	if !ok {
+		parent = &%[1]sFile{name: dir, mode: fs.ModeDir | 0555}
+		fsys.files[dir] = parent
+		fsys.link(parent)
+	}
+	parent.children = append(parent.children, f)
+}
+
+func (fsys *%[1]sFileSystem) lookup(op string, name string) (*%[1]sFile, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	files, err := fsys.snapshot()
+	if err != nil {
+		return nil, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	f, ok := files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return f, nil
+}
+
+func (fsys *%[1]sFileSystem) Open(name string) (fs.File, error) {
+	f, err := fsys.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+	if f.IsDir() {
+		return &%[1]sDir{file: f}, nil
+	}
+	return &%[1]sOpenFile{file: f, Reader: bytes.NewReader(f.data)}, nil
+}
+
+func (fsys *%[1]sFileSystem) ReadDir(name string) ([]fs.DirEntry, error) {
+	f, err := fsys.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if !f.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+	entries := make([]fs.DirEntry, len(f.children))
+	for i, c := range f.children {
+		entries[i] = c
+	}
+	return entries, nil
+}
+
+func (fsys *%[1]sFileSystem) ReadFile(name string) ([]byte, error) {
+	f, err := fsys.lookup("readfile", name)
+	if err != nil {
+		return nil, err
+	}
+	if f.IsDir() {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: errors.New("is a directory")}
+	}
+	return append([]byte(nil), f.data...), nil
+}
+
+func (fsys *%[1]sFileSystem) Stat(name string) (fs.FileInfo, error) {
+	return fsys.lookup("stat", name)
+}
+
+func (fsys *%[1]sFileSystem) Glob(pattern string) ([]string, error) {
+	// hide Glob from fs.Glob so it falls back to walking with ReadDir
+	return fs.Glob(struct{ fs.ReadDirFS }{fsys}, pattern)
+}
+
+// %[1]sOpenFile is an open regular file of %[1]sFS.
+type %[1]sOpenFile struct {
+	*bytes.Reader
+	file *%[1]sFile
+}
+
+func (r *%[1]sOpenFile) Stat() (fs.FileInfo, error) { return r.file, nil }
+func (r *%[1]sOpenFile) Close() error               { return nil }
+
+// %[1]sDir is an open directory of %[1]sFS.
+type %[1]sDir struct {
+	file   *%[1]sFile
+	offset int
+}
+
+func (d *%[1]sDir) Stat() (fs.FileInfo, error) { return d.file, nil }
+func (d *%[1]sDir) Close() error               { return nil }
+
+func (d *%[1]sDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.file.name, Err: errors.New("is a directory")}
+}
+
+func (d *%[1]sDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	children := d.file.children[d.offset:]
+	if n > 0 && len(children) == 0 {
+		return nil, io.EOF
+	}
+	if n > 0 && n < len(children) {
+		children = children[:n]
+	}
+	d.offset += len(children)
+	entries := make([]fs.DirEntry, len(children))
+	for i, c := range children {
+		entries[i] = c
+	}
+	return entries, nil
+}
+%[2]s`
+
+const (
+	// fsSnapshotTemplate parses the archive once on first use.
+	fsSnapshotTemplate string = `
+func (fsys *%[1]sFileSystem) snapshot() (map[string]*%[1]sFile, error) {
+	fsys.once.Do(fsys.load)
+	return fsys.files, fsys.err
+}`
+	// fsDevSnapshotTemplate parses the archive on every call, for the
+	// development file reading it from disk.
+	fsDevSnapshotTemplate string = `
+func (fsys *%[1]sFileSystem) snapshot() (map[string]*%[1]sFile, error) {
+	fresh := new(%[1]sFileSystem)
+	fresh.load()
+	return fresh.files, fresh.err
+}`
+)
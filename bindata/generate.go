@@ -0,0 +1,346 @@
+//
+// Copyright 2020 Alexander Saastamoinen
+//
+//  Licensed under the EUPL, Version 1.2 or – as soon they
+// will be approved by the European Commission - subsequent
+// versions of the EUPL (the "Licence");
+//  You may not use this work except in compliance with the
+// Licence.
+//  You may obtain a copy of the Licence at:
+//
+//  https://joinup.ec.europa.eu/collection/eupl/eupl-text-eupl-12
+//
+//  Unless required by applicable law or agreed to in
+// writing, software distributed under the Licence is
+// distributed on an "AS IS" basis,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied.
+//  See the Licence for the specific language governing
+// permissions and limitations under the Licence.
+//
+
+package bindata
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Options configures Generate, its fields mirror the flags of the embed
+// command and their json names the keys of a config file target, see
+// ParseConfig.
+type Options struct {
+	Paths       []string `json:"paths"`   // files and directories to embed
+	Name        string   `json:"name"`    // generated function name, default bindata
+	PackageName string   `json:"package"` // package of the generated file, default the package found in Dir
+	FileName    string   `json:"output"`  // generated file name, default Name + ".go"
+	Dir         string   `json:"dir"`     // directory the generated files are written to, default the current one
+
+	SkipDir     bool     `json:"skipdir"`   // leave directories out of the archive
+	ParseHidden bool     `json:"hidden"`    // also embed hidden files
+	Recursive   bool     `json:"recursive"` // walk directories recursively
+	KeepRoot    bool     `json:"keeproot"`  // prefix archive paths with the walked directory's name
+	Include     []string `json:"include"`   // globs a file must match one of, if any
+	Exclude     []string `json:"exclude"`   // globs of files and directories to leave out
+	GitIgnore   bool     `json:"gitignore"` // honour .gitignore files next to .embedignore ones
+
+	// Reproducible makes the archive byte-identical across machines and
+	// checkouts, see Maker.Reproducible.
+	Reproducible bool `json:"reproducible"`
+
+	FS          bool `json:"fs"`          // also generate an fs.FS over the archive
+	HTTP        bool `json:"http"`        // also generate an http.Handler over the fs.FS, implies FS
+	Precompress bool `json:"precompress"` // store gzip variants for the http.Handler, implies HTTP
+	// PrecompressMin is the size from which files get a gzip variant, 512
+	// if 0.
+	PrecompressMin int `json:"precompressmin"`
+	// Fallback is the document the http.Handler serves instead of a 404 for
+	// paths without an extension below one of FallbackPrefixes, all paths
+	// if empty. It implies HTTP.
+	Fallback         string   `json:"fallback"`
+	FallbackPrefixes []string `json:"fallbackprefixes"`
+	// Fingerprint holds globs of files to store under content hashed names,
+	// listed in a generated manifest. It implies FS.
+	Fingerprint []string `json:"fingerprint"`
+	// PerFile generates an accessor per file instead of a single function
+	// returning the archive, so unused files are left out of binaries.
+	// PerFileTable, which implies PerFile, adds a table of all of them.
+	PerFile      bool `json:"perfile"`
+	PerFileTable bool `json:"perfiletable"`
+	// Consts generates a typed constant per file holding its archive path,
+	// so referencing a removed file fails to compile.
+	Consts bool `json:"consts"`
+	// SRI generates the sha256 and sha384 Subresource Integrity digests of
+	// the files and an Integrity lookup. It implies FS.
+	SRI         bool   `json:"sri"`
+	Compression string `json:"compress"` // gzip, zlib or flate, empty stores data uncompressed
+	Level       int    `json:"level"`    // compression level from -2 to 9, 0 is the codec default, see NoCompression
+	Encoding    string `json:"encoding"` // bytes, string or asm, default string with ZeroCopy and bytes otherwise
+	ZeroCopy    bool   `json:"zerocopy"` // also generate accessors sharing the read-only data
+	Bench       bool   `json:"bench"`    // also generate a benchmark of the accessors, implies ZeroCopy
+
+	// Groups embeds the paths of each group under an accessor named after
+	// it instead of Paths under Name, sharing the other options. The groups
+	// are generated concurrently into FileName, or into a file named after
+	// each group if GroupFiles is set.
+	Groups     []Group `json:"groups"`
+	GroupFiles bool    `json:"groupfiles"`
+
+	// Dev also generates a FileName + "_dev.go" file reading Paths from
+	// disk, selected by the embed_dev build tag instead of the embedded data.
+	Dev bool `json:"dev"`
+
+	// Check compares the generated files to the existing ones instead of
+	// writing them, returning a *DriftError if they differ.
+	Check bool `json:"-"`
+	// Force regenerates the files even if the hash of the inputs and
+	// options recorded in the existing file matches. Without it an up to
+	// date file is left untouched, keeping the build cache valid.
+	Force bool `json:"-"`
+
+	// Output receives the generated Go source instead of FileName when set.
+	// The asm encoding, Dev and Bench produce additional files, which then
+	// go to AsmOutput, DevOutput and BenchOutput.
+	Output      io.Writer `json:"-"`
+	AsmOutput   io.Writer `json:"-"`
+	DevOutput   io.Writer `json:"-"`
+	BenchOutput io.Writer `json:"-"`
+}
+
+// Result describes what Generate produced.
+type Result struct {
+	PackageName string
+	Files       []string // written files, empty when writing to Options.Output
+	Archived    bool     // the data is a tar archive rather than a single file
+	Size        int      // size of the data
+	StoredSize  int      // size of the data as stored, after compression
+	Unchanged   bool     // the inputs matched the existing files, which were left as is
+}
+
+// Generate walks opts.Paths, or the paths of opts.Groups, and generates the
+// Go source embedding them.
+func Generate(ctx context.Context, opts Options) (Result, error) {
+	var res Result
+	if opts.Name == "" {
+		opts.Name = "bindata"
+	}
+	if opts.FileName == "" {
+		opts.FileName = opts.Name + ".go"
+	}
+	if opts.Dir == "" {
+		opts.Dir = "."
+	}
+	if (opts.PerFile || opts.PerFileTable) && (opts.Dev || opts.Bench) {
+		return res, errors.New("per file accessors cannot be combined with Dev or Bench")
+	}
+	if opts.Check && opts.Output != nil {
+		return res, errors.New("Check compares against the generated files, Output must not be set")
+	}
+	if opts.Output != nil && ((opts.Encoding == "asm" && opts.AsmOutput == nil) || (opts.Dev && opts.DevOutput == nil) || (opts.Bench && opts.BenchOutput == nil)) {
+		return res, errors.New("Output is set but the AsmOutput, DevOutput or BenchOutput needed for the additional files is not")
+	}
+	m := opts.maker()
+
+	res.PackageName = opts.PackageName
+	if res.PackageName == "" {
+		name, err := FindPackageName(opts.Dir)
+		if err != nil {
+			return res, err
+		}
+		res.PackageName = name
+	}
+	opts.PackageName = res.PackageName
+	if len(opts.Groups) > 0 {
+		return generateGroups(ctx, opts, res)
+	}
+
+	files, err := m.openFiles(ctx, opts.Paths)
+	if err != nil {
+		return res, err
+	}
+	if err := ctx.Err(); err != nil {
+		closeEntries(files)
+		return res, err
+	}
+	if m.inputHash, err = m.hashInputs(files, opts); err != nil {
+		closeEntries(files)
+		return res, err
+	}
+	if !opts.Force && !opts.Check && opts.Output == nil {
+		if names, ok := opts.upToDate(m.inputHash); ok {
+			closeEntries(files)
+			res.Files, res.Unchanged = names, true
+			return res, nil
+		}
+	}
+	data, err := m.MakeTar(files)
+	if err != nil {
+		return res, err
+	}
+	raw := data.Bytes()
+	res.Archived, res.Size = m.isTar, data.Len()
+	if m.Compression != "" {
+		if data, err = m.MakeCompressed(data); err != nil {
+			return res, err
+		}
+	}
+	res.StoredSize = data.Len()
+
+	base := filepath.Join(opts.Dir, strings.TrimSuffix(opts.FileName, ".go"))
+	var outputs []output
+	if m.Encoding == "asm" {
+		buf, err := m.MakeAsm(bytes.NewBuffer(data.Bytes()), opts.Name)
+		if err != nil {
+			return res, err
+		}
+		outputs = append(outputs, output{base + ".s", opts.AsmOutput, buf})
+	}
+	buf, err := m.MakeSource(data, res.PackageName, opts.Name)
+	if err != nil {
+		return res, err
+	}
+	goFile := filepath.Join(opts.Dir, opts.FileName)
+	outputs = append(outputs, output{goFile, opts.Output, buf})
+	if opts.Dev {
+		paths, err := devPaths(opts.Dir, opts.Paths)
+		if err != nil {
+			return res, err
+		}
+		buf, err := m.MakeDev(res.PackageName, opts.Name, paths)
+		if err != nil {
+			return res, err
+		}
+		outputs = append(outputs, output{base + "_dev.go", opts.DevOutput, buf})
+	}
+	if opts.Bench {
+		buf, err := m.MakeBench(res.PackageName, opts.Name)
+		if err != nil {
+			return res, err
+		}
+		if opts.BenchOutput == nil && !opts.Check {
+			// a _test.go file may well be written by hand
+			if err := checkGenerated(base + "_test.go"); err != nil {
+				return res, err
+			}
+		}
+		outputs = append(outputs, output{base + "_test.go", opts.BenchOutput, buf})
+	}
+
+	if err := ctx.Err(); err != nil {
+		return res, err
+	}
+	if opts.Check {
+		return res, check(outputs, goFile, opts.Name, raw, m.isTar)
+	}
+	return res.write(outputs)
+}
+
+// write writes outputs, adding the written files to res.
+func (res Result) write(outputs []output) (Result, error) {
+	for _, o := range outputs {
+		if o.w != nil {
+			if _, err := o.buf.WriteTo(o.w); err != nil {
+				return res, err
+			}
+			continue
+		}
+		if err := writeFile(o.name, o.buf); err != nil {
+			return res, err
+		}
+		res.Files = append(res.Files, o.name)
+	}
+	return res, nil
+}
+
+// output is a generated file, written to w if set and to the file name
+// otherwise.
+type output struct {
+	name string
+	w    io.Writer
+	buf  *bytes.Buffer
+}
+
+// devPaths returns paths relative to dir for the development file, or
+// absolute if they cannot be made relative.
+func devPaths(dir string, paths []string) ([]string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	rel := make([]string, len(paths))
+	for i, p := range paths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return nil, err
+		}
+		if rel[i], err = filepath.Rel(absDir, abs); err != nil {
+			rel[i] = abs
+		}
+		rel[i] = filepath.ToSlash(rel[i])
+	}
+	return rel, nil
+}
+
+// upToDate reports whether the files generated for opts exist and the Go
+// file records hash, returning their names.
+func (opts Options) upToDate(hash string) ([]string, bool) {
+	base := filepath.Join(opts.Dir, strings.TrimSuffix(opts.FileName, ".go"))
+	goFile := filepath.Join(opts.Dir, opts.FileName)
+	var names []string
+	if opts.Encoding == "asm" {
+		names = append(names, base+".s")
+	}
+	names = append(names, goFile)
+	if opts.Dev {
+		names = append(names, base+"_dev.go")
+	}
+	if opts.Bench {
+		names = append(names, base+"_test.go")
+	}
+	for _, name := range names {
+		if _, err := os.Stat(name); err != nil {
+			return nil, false
+		}
+	}
+	old, err := readInputHash(goFile)
+	return names, err == nil && old == hash
+}
+
+// maker returns the Maker configured by opts.
+func (opts Options) maker() *Maker {
+	m := &Maker{
+		SkipDir:          opts.SkipDir,
+		ParseHidden:      opts.ParseHidden,
+		Recurssive:       opts.Recursive,
+		KeepRoot:         opts.KeepRoot,
+		Include:          opts.Include,
+		Exclude:          opts.Exclude,
+		GitIgnore:        opts.GitIgnore,
+		Reproducible:     opts.Reproducible,
+		FS:               opts.FS || opts.HTTP || opts.Precompress || opts.Fallback != "" || len(opts.Fingerprint) > 0 || opts.SRI,
+		HTTP:             opts.HTTP || opts.Precompress || opts.Fallback != "",
+		Precompress:      opts.Precompress,
+		PrecompressMin:   opts.PrecompressMin,
+		Fingerprint:      opts.Fingerprint,
+		SRI:              opts.SRI,
+		PerFile:          opts.PerFile || opts.PerFileTable,
+		PerFileTable:     opts.PerFileTable,
+		Consts:           opts.Consts,
+		Fallback:         opts.Fallback,
+		FallbackPrefixes: opts.FallbackPrefixes,
+		Compression:      opts.Compression,
+		Level:            opts.Level,
+		Encoding:         opts.Encoding,
+		ZeroCopy:         opts.ZeroCopy || opts.Bench,
+		Dev:              opts.Dev,
+	}
+	if m.Encoding == "" && m.ZeroCopy {
+		m.Encoding = "string"
+	}
+	return m
+}
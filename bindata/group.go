@@ -0,0 +1,274 @@
+//
+// Copyright 2020 Alexander Saastamoinen
+//
+//  Licensed under the EUPL, Version 1.2 or – as soon they
+// will be approved by the European Commission - subsequent
+// versions of the EUPL (the "Licence");
+//  You may not use this work except in compliance with the
+// Licence.
+//  You may obtain a copy of the Licence at:
+//
+//  https://joinup.ec.europa.eu/collection/eupl/eupl-text-eupl-12
+//
+//  Unless required by applicable law or agreed to in
+// writing, software distributed under the Licence is
+// distributed on an "AS IS" basis,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied.
+//  See the Licence for the specific language governing
+// permissions and limitations under the Licence.
+//
+
+package bindata
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go/token"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Group is a set of paths embedded under an accessor of its own, see
+// Options.Groups.
+type Group struct {
+	Name  string   `json:"name"`  // name of the accessor, used like Options.Name
+	Paths []string `json:"paths"` // files and directories to embed
+}
+
+// groupJob generates the declarations of a single group.
+type groupJob struct {
+	opts  Options
+	m     *Maker
+	files []*Entry
+	raw   []byte        // the data before compression
+	data  *bytes.Buffer // the data as stored
+	body  *bytes.Buffer // the declarations following the imports
+	asm   *bytes.Buffer // the DATA directives with the asm encoding
+}
+
+// checkGroups reports invalid or clashing groups.
+func (opts Options) checkGroups() error {
+	if len(opts.Paths) > 0 {
+		return errors.New("Paths and Groups cannot be combined")
+	}
+	seen := make(map[string]bool, len(opts.Groups))
+	for _, g := range opts.Groups {
+		switch {
+		case !token.IsIdentifier(g.Name):
+			return fmt.Errorf("group name %q is not a Go identifier", g.Name)
+		case seen[g.Name]:
+			return fmt.Errorf("group %s given twice", g.Name)
+		case len(g.Paths) == 0:
+			return fmt.Errorf("group %s has no paths", g.Name)
+		}
+		seen[g.Name] = true
+	}
+	if opts.GroupFiles {
+		if opts.Output != nil {
+			return errors.New("GroupFiles writes a file per group, Output must not be set")
+		}
+		return nil
+	}
+	if opts.Dev || opts.Bench {
+		return errors.New("groups sharing a file cannot be combined with Dev or Bench, set GroupFiles")
+	}
+	return nil
+}
+
+// group returns the options generating g alone.
+func (opts Options) group(g Group) Options {
+	opts.Name, opts.Paths, opts.Groups, opts.GroupFiles = g.Name, g.Paths, nil, false
+	return opts
+}
+
+// concurrently runs fn for every group, returning the errors of all of
+// them.
+func concurrently(groups []Group, fn func(i int) error) error {
+	errs := make([]error, len(groups))
+	var wg sync.WaitGroup
+	for i := range groups {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := fn(i); err != nil {
+				errs[i] = fmt.Errorf("group %s: %w", groups[i].Name, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// generateGroups is Generate for opts.Groups, res holds the package name.
+func generateGroups(ctx context.Context, opts Options, res Result) (Result, error) {
+	if err := opts.checkGroups(); err != nil {
+		return res, err
+	}
+	if opts.GroupFiles {
+		return generateGroupFiles(ctx, opts, res)
+	}
+
+	jobs := make([]*groupJob, len(opts.Groups))
+	defer func() {
+		for _, j := range jobs {
+			if j != nil && j.data == nil {
+				closeEntries(j.files)
+			}
+		}
+	}()
+	err := concurrently(opts.Groups, func(i int) error {
+		j := &groupJob{opts: opts.group(opts.Groups[i])}
+		j.m = j.opts.maker()
+		jobs[i] = j
+		var err error
+		if j.files, err = j.m.openFiles(ctx, j.opts.Paths); err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		j.m.inputHash, err = j.m.hashInputs(j.files, j.opts)
+		return err
+	})
+	if err != nil {
+		return res, err
+	}
+	// the file records a single hash covering every group
+	h := sha256.New()
+	for _, j := range jobs {
+		fmt.Fprintln(h, j.m.inputHash)
+		j.m.inputHash = ""
+	}
+	hash := "sha256:" + hex.EncodeToString(h.Sum(nil))
+	if !opts.Force && !opts.Check && opts.Output == nil {
+		if names, ok := opts.upToDate(hash); ok {
+			res.Files, res.Unchanged = names, true
+			return res, nil
+		}
+	}
+
+	err = concurrently(opts.Groups, func(i int) error {
+		j := jobs[i]
+		data, err := j.m.MakeTar(j.files)
+		if err != nil {
+			return err
+		}
+		j.raw = data.Bytes()
+		if j.m.Compression != "" {
+			if data, err = j.m.MakeCompressed(data); err != nil {
+				return err
+			}
+		}
+		j.data = data
+		if j.m.Encoding == "asm" {
+			if j.asm, err = j.m.MakeAsm(bytes.NewBuffer(data.Bytes()), j.opts.Name); err != nil {
+				return err
+			}
+		}
+		j.body = new(bytes.Buffer)
+		return j.m.writeSource(j.body, bytes.NewBuffer(data.Bytes()), j.opts.Name)
+	})
+	if err != nil {
+		return res, err
+	}
+
+	header := fmt.Sprintf(hashComment, hash)
+	var imports []string
+	var size int
+	for _, j := range jobs {
+		pkgs, err := j.m.sourceImports(j.opts.Name, j.data.Len())
+		if err != nil {
+			return res, err
+		}
+		imports = append(imports, pkgs...)
+		header += j.m.headerComments(j.opts.Name)
+		size += j.body.Len()
+		res.Archived = res.Archived || j.m.isTar
+		res.Size += len(j.raw)
+		res.StoredSize += j.data.Len()
+	}
+	buf := new(bytes.Buffer)
+	buf.Grow(size + len(header) + 256)
+	if _, err := fmt.Fprintf(buf, preTemplate, res.PackageName, header, importBlock(imports)); err != nil {
+		return res, err
+	}
+	for _, j := range jobs {
+		j.body.WriteTo(buf)
+	}
+
+	base := filepath.Join(opts.Dir, strings.TrimSuffix(opts.FileName, ".go"))
+	goFile := filepath.Join(opts.Dir, opts.FileName)
+	var outputs []output
+	if opts.Encoding == "asm" {
+		asm := new(bytes.Buffer)
+		for i, j := range jobs {
+			if i > 0 {
+				j.asm.Next(len(asmTemplate))
+			}
+			j.asm.WriteTo(asm)
+		}
+		outputs = append(outputs, output{base + ".s", opts.AsmOutput, asm})
+	}
+	outputs = append(outputs, output{goFile, opts.Output, buf})
+
+	if err := ctx.Err(); err != nil {
+		return res, err
+	}
+	if opts.Check {
+		return res, checkGroupFile(outputs, goFile, jobs)
+	}
+	return res.write(outputs)
+}
+
+// checkGroupFile is check for a file holding jobs, archive entries are listed
+// as group/path.
+func checkGroupFile(outputs []output, goFile string, jobs []*groupJob) error {
+	var drift *DriftError
+	for _, j := range jobs {
+		err := check(outputs, goFile, j.opts.Name, j.raw, j.m.isTar)
+		d, ok := err.(*DriftError)
+		if !ok {
+			return err
+		}
+		if drift == nil {
+			drift = &DriftError{Files: d.Files}
+		}
+		prefix := func(names []string) []string {
+			for i := range names {
+				names[i] = j.opts.Name + "/" + names[i]
+			}
+			return names
+		}
+		drift.Added = append(drift.Added, prefix(d.Added)...)
+		drift.Removed = append(drift.Removed, prefix(d.Removed)...)
+		drift.Changed = append(drift.Changed, prefix(d.Changed)...)
+	}
+	return drift
+}
+
+// generateGroupFiles generates a file named after each group of opts.
+func generateGroupFiles(ctx context.Context, opts Options, res Result) (Result, error) {
+	results := make([]Result, len(opts.Groups))
+	err := concurrently(opts.Groups, func(i int) error {
+		g := opts.group(opts.Groups[i])
+		g.FileName = g.Name + ".go"
+		var err error
+		results[i], err = Generate(ctx, g)
+		return err
+	})
+	res.Unchanged = true
+	for _, r := range results {
+		res.Files = append(res.Files, r.Files...)
+		res.Archived = res.Archived || r.Archived
+		res.Size += r.Size
+		res.StoredSize += r.StoredSize
+		res.Unchanged = res.Unchanged && r.Unchanged
+	}
+	return res, err
+}
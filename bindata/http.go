@@ -0,0 +1,309 @@
+//
+// Copyright 2020 Alexander Saastamoinen
+//
+//  Licensed under the EUPL, Version 1.2 or – as soon they
+// will be approved by the European Commission - subsequent
+// versions of the EUPL (the "Licence");
+//  You may not use this work except in compliance with the
+// Licence.
+//  You may obtain a copy of the Licence at:
+//
+//  https://joinup.ec.europa.eu/collection/eupl/eupl-text-eupl-12
+//
+//  Unless required by applicable law or agreed to in
+// writing, software distributed under the Licence is
+// distributed on an "AS IS" basis,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied.
+//  See the Licence for the specific language governing
+// permissions and limitations under the Licence.
+//
+
+package bindata
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+// httpImports are the packages used by httpTemplate on top of fsImports.
+var httpImports = []string{"mime", "net/http", "strconv", "strings"}
+
+// httpTemplate is appended after fsTemplate when Maker.HTTP is set, %[2]s
+// is the literal of the ETag map, %[3]s and %[4]s the defaults of the
+// fallback document and the prefixes it covers.
+const httpTemplate string = `
+
+// %[1]sHandler serves the files of %[1]sFS over HTTP, directories with
+// their index.html.
+var %[1]sHandler http.Handler = %[1]sServer{}
+
+// %[1]sETags holds the strong ETag of every regular file of %[1]sFS,
+// computed when the file was generated. It is empty in development mode.
+var %[1]sETags = %[2]s
+
+// %[1]sFallback is the document served instead of a 404 for paths without
+// an extension below one of %[1]sFallbackPrefixes, for single page
+// applications routing on the client. Empty disables the fallback.
+var %[1]sFallback = %[3]q
+
+// %[1]sFallbackPrefixes are the URL paths %[1]sFallback covers.
+var %[1]sFallbackPrefixes = %[4]s
+
+// %[1]sFallsBack reports whether %[1]sFallback is served for the missing
+// URL path p.
+func %[1]sFallsBack(p string) bool {
+	if %[1]sFallback == "" || path.Ext(p) != "" {
+		return false
+	}
+	p = path.Clean("/" + p)
+	for _, prefix := range %[1]sFallbackPrefixes {
+		prefix = path.Clean("/" + prefix)
+		if p == prefix || strings.HasPrefix(p, strings.TrimSuffix(prefix, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// %[1]sServer implements %[1]sHandler.
+type %[1]sServer struct{}
+
+func (%[1]sServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	if name == "" {
+		name = "."
+	}
+	f, err := %[1]sFS.lookup("open", name)
+	if err == nil && f.IsDir() {
+		if r.URL.Path != "" && !strings.HasSuffix(r.URL.Path, "/") {
+			http.Redirect(w, r, path.Base(r.URL.Path)+"/", http.StatusMovedPermanently)
+			return
+		}
+		name = path.Join(name, "index.html")
+		f, err = %[1]sFS.lookup("open", name)
+	}
+	if (errors.Is(err, fs.ErrNotExist) || err == nil && f.IsDir()) && %[1]sFallsBack(r.URL.Path) {
+		name = strings.TrimPrefix(%[1]sAssetURL(%[1]sFallback), "/")
+		f, err = %[1]sFS.lookup("open", name)
+	}
+	switch {
+	case errors.Is(err, fs.ErrNotExist) || err == nil && f.IsDir():
+		http.NotFound(w, r)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if %[1]sImmutable[name] {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	}
+	etag, hasETag := %[1]sETags[name]
+	if gz, ok := %[1]sGzipped[name]; ok {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if %[1]sAcceptsGzip(r) {
+			ctype := mime.TypeByExtension(path.Ext(name))
+			if ctype == "" {
+				ctype = http.DetectContentType(f.data)
+			}
+			w.Header().Set("Content-Type", ctype)
+			w.Header().Set("Content-Encoding", "gzip")
+			if hasETag {
+				w.Header().Set("ETag", strings.TrimSuffix(etag, "\"")+"-gzip\"")
+			}
+			http.ServeContent(w, r, name, f.modTime, strings.NewReader(gz))
+			return
+		}
+	}
+	if hasETag {
+		w.Header().Set("ETag", etag)
+	}
+	http.ServeContent(w, r, name, f.modTime, bytes.NewReader(f.data))
+}
+
+// %[1]sAcceptsGzip reports whether the Accept-Encoding header of r allows a
+// gzip encoded response.
+func %[1]sAcceptsGzip(r *http.Request) bool {
+	for _, v := range r.Header.Values("Accept-Encoding") {
+		for _, coding := range strings.Split(v, ",") {
+			coding, params, _ := strings.Cut(coding, ";")
+			coding = strings.TrimSpace(coding)
+			if coding != "gzip" && coding != "*" {
+				continue
+			}
+			q := 1.0
+			if p := strings.TrimSpace(params); strings.HasPrefix(p, "q=") {
+				q, _ = strconv.ParseFloat(p[2:], 64)
+			}
+			return q > 0
+		}
+	}
+	return false
+}
+`
+
+const (
+	// gzippedTemplate declares the map of precompressed variants used by
+	// httpTemplate, %[2]s is the minimum size of a compressed file.
+	gzippedTemplate string = `
+// %[1]sGzipped holds gzip compressed variants of the files of %[1]sFS of at
+// least %[2]d bytes that are smaller compressed, keyed by path.
+var %[1]sGzipped = map[string]string{`
+
+	// gzippedDefault is the default Maker.PrecompressMin.
+	gzippedDefault int = 512
+)
+
+// writeGzipped writes the %[1]sGzipped map of precompressed variants of the
+// regular files in archive, empty unless m.Precompress is set.
+func (m *Maker) writeGzipped(buf *bytes.Buffer, funcName string, archive []byte) error {
+	min := m.PrecompressMin
+	if min <= 0 {
+		min = gzippedDefault
+	}
+	if _, err := fmt.Fprintf(buf, gzippedTemplate, funcName, min); err != nil {
+		return err
+	}
+	if !m.Precompress {
+		buf.WriteString("}\n")
+		return nil
+	}
+	variants := make(map[string][]byte)
+	var names []string
+	r := tar.NewReader(bytes.NewReader(archive))
+	for {
+		h, err := r.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		if h.Typeflag != tar.TypeReg || h.Size < int64(min) {
+			continue
+		}
+		var gz bytes.Buffer
+		w, err := gzip.NewWriterLevel(&gz, gzip.BestCompression)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(w, r); err != nil {
+			return err
+		}
+		if err := w.Close(); err != nil {
+			return err
+		}
+		if int64(gz.Len()) < h.Size {
+			name := path.Clean(h.Name)
+			variants[name] = gz.Bytes()
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	buf.WriteByte('\n')
+	for _, name := range names {
+		fmt.Fprintf(buf, "\t%q: ", name)
+		writeStringLiteral(buf, variants[name])
+		buf.WriteString(",\n")
+	}
+	buf.WriteString("}\n")
+	return nil
+}
+
+// fallback returns the literals of the default fallback document and the
+// prefixes it covers.
+func (m *Maker) fallback() (string, string) {
+	prefixes := m.FallbackPrefixes
+	if len(prefixes) == 0 {
+		prefixes = []string{"/"}
+	}
+	return m.Fallback, fmt.Sprintf("%#v", prefixes)
+}
+
+// checkFallback returns an error if m.Fallback is set but not a file in
+// archive.
+func (m *Maker) checkFallback(archive []byte) error {
+	if m.Fallback == "" {
+		return nil
+	}
+	name := strings.TrimPrefix(m.Fallback, "/")
+	if hashed, ok := m.manifest[name]; ok {
+		name = hashed
+	}
+	entries, err := ListArchive(archive)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.Name == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("fallback document %s is not archived", m.Fallback)
+}
+
+// archiveData returns the uncompressed archive read from rawBuf, which
+// MakeTar keeps for compressed data.
+func (m *Maker) archiveData(raw []byte) ([]byte, error) {
+	switch {
+	case m.archive != nil:
+		return m.archive, nil
+	case m.Compression == "":
+		return raw, nil
+	}
+	return nil, errors.New("the compressed archive must be made by MakeTar")
+}
+
+// etags returns the source of a map from the path of each regular file in
+// archive to its strong ETag.
+func etags(archive []byte) (string, error) {
+	tags := make(map[string]string)
+	r := tar.NewReader(bytes.NewReader(archive))
+	for {
+		h, err := r.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return "", err
+		}
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+		sum := sha256.New()
+		if _, err := io.Copy(sum, r); err != nil {
+			return "", err
+		}
+		tags[path.Clean(h.Name)] = `"` + hex.EncodeToString(sum.Sum(nil)[:16]) + `"`
+	}
+	return mapLiteral(tags), nil
+}
+
+// mapLiteral returns the source of a map[string]string holding m, sorted by
+// key.
+func mapLiteral(m map[string]string) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteString("map[string]string{\n")
+	for _, k := range keys {
+		fmt.Fprintf(&b, "\t%q: %q,\n", k, m[k])
+	}
+	b.WriteString("}")
+	return b.String()
+}
@@ -0,0 +1,146 @@
+//
+// Copyright 2020 Alexander Saastamoinen
+//
+//  Licensed under the EUPL, Version 1.2 or – as soon they
+// will be approved by the European Commission - subsequent
+// versions of the EUPL (the "Licence");
+//  You may not use this work except in compliance with the
+// Licence.
+//  You may obtain a copy of the Licence at:
+//
+//  https://joinup.ec.europa.eu/collection/eupl/eupl-text-eupl-12
+//
+//  Unless required by applicable law or agreed to in
+// writing, software distributed under the Licence is
+// distributed on an "AS IS" basis,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied.
+//  See the Licence for the specific language governing
+// permissions and limitations under the Licence.
+//
+
+package bindata
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// embedIgnore is the name of the ignore files honoured in walked directories.
+const embedIgnore string = ".embedignore"
+
+// ignoreRule is a single pattern of an ignore file.
+type ignoreRule struct {
+	pattern string // glob relative to the directory of the ignore file
+	source  string // the ignore file
+	negate  bool
+	dirOnly bool
+}
+
+// parseIgnore reads the gitignore syntax rules of the file name.
+func parseIgnore(name string) ([]ignoreRule, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var rules []ignoreRule
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSuffix(s.Text(), "\r")
+		for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+			line = line[:len(line)-1]
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		r := ignoreRule{source: name}
+		if strings.HasPrefix(line, "!") {
+			r.negate = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			r.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		if strings.Contains(line, "/") {
+			// anchored to the directory of the ignore file
+			line = strings.TrimPrefix(line, "/")
+		} else {
+			line = "**/" + line
+		}
+		if strings.HasSuffix(line, "/**") {
+			// foo/** matches what is inside foo, not foo itself
+			line = strings.TrimSuffix(line, "**") + "*/**"
+		}
+		r.pattern = line
+		rules = append(rules, r)
+	}
+	return rules, s.Err()
+}
+
+// ignorer holds the ignore files found while walking a single root.
+type ignorer struct {
+	names []string // ignore file names, later ones take precedence
+	rules map[string][]ignoreRule
+}
+
+func (m *Maker) newIgnorer() *ignorer {
+	ig := &ignorer{names: []string{embedIgnore}, rules: make(map[string][]ignoreRule)}
+	if m.GitIgnore {
+		ig.names = []string{".gitignore", embedIgnore}
+	}
+	return ig
+}
+
+// load reads the ignore files of dir, whose path relative to the root is rel.
+func (ig *ignorer) load(dir string, rel string) error {
+	for _, n := range ig.names {
+		rules, err := parseIgnore(filepath.Join(dir, n))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		ig.rules[rel] = append(ig.rules[rel], rules...)
+	}
+	return nil
+}
+
+// ignored reports whether the path rel, relative to the root, is ignored by
+// the ignore files of its parent directories. The last matching rule wins,
+// rules of deeper directories come later.
+func (ig *ignorer) ignored(rel string, isDir bool) (bool, error) {
+	ignored := false
+	dir, rest := ".", rel
+	for {
+		for _, r := range ig.rules[dir] {
+			if r.dirOnly && !isDir {
+				continue
+			}
+			ok, err := matchGlob(r.pattern, rest)
+			if err != nil {
+				return false, fmt.Errorf("%s: %w", r.source, err)
+			}
+			if ok {
+				ignored = !r.negate
+			}
+		}
+		i := strings.IndexByte(rest, '/')
+		if i < 0 {
+			return ignored, nil
+		}
+		if dir == "." {
+			dir = rest[:i]
+		} else {
+			dir += "/" + rest[:i]
+		}
+		rest = rest[i+1:]
+	}
+}
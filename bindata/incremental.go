@@ -0,0 +1,105 @@
+//
+// Copyright 2020 Alexander Saastamoinen
+//
+//  Licensed under the EUPL, Version 1.2 or – as soon they
+// will be approved by the European Commission - subsequent
+// versions of the EUPL (the "Licence");
+//  You may not use this work except in compliance with the
+// Licence.
+//  You may obtain a copy of the Licence at:
+//
+//  https://joinup.ec.europa.eu/collection/eupl/eupl-text-eupl-12
+//
+//  Unless required by applicable law or agreed to in
+// writing, software distributed under the Licence is
+// distributed on an "AS IS" basis,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied.
+//  See the Licence for the specific language governing
+// permissions and limitations under the Licence.
+//
+
+package bindata
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// hashInputs returns a hash of everything the generated files depend on:
+// the options and the archive header and content of each file. Changes to
+// the generated code of embed itself are not covered, see Options.Force.
+func (m *Maker) hashInputs(files []*Entry, opts Options) (string, error) {
+	h := sha256.New()
+	// destinations and the flags not affecting the output are left out,
+	// the paths only matter to the development file, which records them
+	// relative to Dir
+	var paths []string
+	if opts.Dev {
+		var err error
+		if paths, err = devPaths(opts.Dir, opts.Paths); err != nil {
+			return "", err
+		}
+	}
+	opts.Paths, opts.Dir, opts.Check, opts.Force = paths, "", false, false
+	opts.Output, opts.AsmOutput, opts.DevOutput, opts.BenchOutput = nil, nil, nil, nil
+	fmt.Fprintf(h, "%#v\n", opts)
+
+	var epoch time.Time
+	if m.Reproducible {
+		var err error
+		if epoch, err = sourceDateEpoch(); err != nil {
+			return "", err
+		}
+	}
+	for _, f := range files {
+		head, err := m.header(f, epoch)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%q %c %o %d %d %q %q %d %d %q\n", head.Name, head.Typeflag, head.Mode, head.Uid, head.Gid,
+			head.Uname, head.Gname, head.ModTime.UnixNano(), head.Size, head.Linkname)
+		if head.FileInfo().IsDir() {
+			continue
+		}
+		if _, err := io.Copy(h, f.File); err != nil {
+			return "", fmt.Errorf("hashing %s: %w", f.File.Name(), err)
+		}
+		if _, err := f.File.Seek(0, io.SeekStart); err != nil {
+			return "", err
+		}
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// readInputHash returns the input hash recorded in the header of the
+// generated file name, or "" if there is none.
+func readInputHash(name string) (string, error) {
+	f, err := os.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	prefix := strings.TrimSuffix(hashComment, "%s\n")
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, prefix) {
+			return line[len(prefix):], nil
+		}
+		if strings.HasPrefix(line, "import") || strings.HasPrefix(line, "func") {
+			// past the header
+			break
+		}
+	}
+	return "", scanner.Err()
+}
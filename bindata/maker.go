@@ -0,0 +1,674 @@
+//
+// Copyright 2020 Alexander Saastamoinen
+//
+//  Licensed under the EUPL, Version 1.2 or – as soon they
+// will be approved by the European Commission - subsequent
+// versions of the EUPL (the "Licence");
+//  You may not use this work except in compliance with the
+// Licence.
+//  You may obtain a copy of the Licence at:
+//
+//  https://joinup.ec.europa.eu/collection/eupl/eupl-text-eupl-12
+//
+//  Unless required by applicable law or agreed to in
+// writing, software distributed under the Licence is
+// distributed on an "AS IS" basis,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied.
+//  See the Licence for the specific language governing
+// permissions and limitations under the Licence.
+//
+
+// Package bindata generates Go source files embedding files and directories
+// as a []byte returning function, tar archiving them when there is more than
+// one. The embed command is a thin wrapper around Generate.
+package bindata
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	tarReminder string = "//variable contains a tar archive"
+	preTemplate string = `package %s
+
+//autogenerated by embed
+%s%s`
+	// hashComment records the input hash, see Options.Force.
+	hashComment string = "//embed:inputs %s\n"
+	// perFileComment names the function whose data is split into per
+	// file accessors, see ReadSource.
+	perFileComment string = "//embed:perfile %s\n"
+	dataTemplate   string = `
+%s
+func %s() []byte {
+	var bindata = []byte{`
+
+	postTemplate string = `}
+	return bindata
+}`
+)
+
+var ()
+
+// importBlock returns a sorted import declaration for pkgs, or nothing if
+// empty. A package may be given as "name path" to import it under name.
+func importBlock(pkgs []string) string {
+	if len(pkgs) == 0 {
+		return ""
+	}
+	set := make(map[string]bool, len(pkgs))
+	var sorted []string
+	for _, p := range pkgs {
+		if !set[p] {
+			set[p] = true
+			sorted = append(sorted, p)
+		}
+	}
+	importPath := func(p string) string { return p[strings.LastIndex(p, " ")+1:] }
+	sort.Slice(sorted, func(i, j int) bool { return importPath(sorted[i]) < importPath(sorted[j]) })
+	var b strings.Builder
+	b.WriteString("\nimport (\n")
+	for _, p := range sorted {
+		b.WriteByte('\t')
+		if name, path, ok := strings.Cut(p, " "); ok {
+			b.WriteString(name + " ")
+			p = path
+		}
+		b.WriteString(strconv.Quote(p) + "\n")
+	}
+	b.WriteString(")\n")
+	return b.String()
+}
+
+// FindPackageName returns the name of the single Go package in dir.
+func FindPackageName(dir string) (name string, err error) {
+	fset := token.NewFileSet()
+	fMap, err := parser.ParseDir(fset, dir, nil, parser.PackageClauseOnly)
+	if err != nil {
+		return
+	}
+	if len(fMap) != 1 {
+		err = errors.New("expected only one package in " + dir + ", found: " + strconv.Itoa(len(fMap)))
+		return
+	}
+	for k := range fMap {
+		if k == "" {
+			err = errors.New(dir + " package has empty name")
+			return
+		}
+		name = k
+	}
+	return name, nil
+}
+
+// OpenFiles walks paths concurrently and opens every file to embed. Errors
+// of all walkers are joined, in which case no files are returned.
+func (m *Maker) OpenFiles(paths []string) ([]*Entry, error) {
+	return m.openFiles(context.Background(), paths)
+}
+
+func (m *Maker) openFiles(ctx context.Context, paths []string) ([]*Entry, error) {
+	out := make(chan walkResult)
+	var wg sync.WaitGroup
+	for i, p := range paths {
+		wg.Add(1)
+		go m.parsePath(ctx, i, p, out, &wg)
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	// keep the order of paths whatever order the walkers finish in
+	results := make([]walkResult, len(paths))
+	for r := range out {
+		results[r.index] = r
+	}
+	var files []*Entry
+	var errs []error
+	for _, r := range results {
+		files = append(files, r.files...)
+		errs = append(errs, r.err)
+	}
+	if err := errors.Join(errs...); err != nil {
+		closeEntries(files)
+		return nil, err
+	}
+	return files, nil
+}
+
+// Maker collects files and generates the source embedding them, its fields
+// select what is walked and what is generated.
+type Maker struct {
+	SkipDir     bool
+	ParseHidden bool
+	Recurssive  bool
+	KeepRoot    bool
+	Include     []string // globs a file must match one of, if any
+	Exclude     []string // globs of files and directories to leave out
+	GitIgnore   bool     // honour .gitignore files next to .embedignore ones
+	FS          bool     // also generate an fs.FS over the archive
+	Compression string   // gzip, zlib or flate, empty stores data uncompressed
+	Level       int      // compression level from -2 to 9, 0 is the codec default, see NoCompression
+	Encoding    string   // bytes (default), string or asm
+	ZeroCopy    bool     // also generate accessors sharing the read-only data
+	// Reproducible sorts archive entries and normalises their headers so
+	// the output does not depend on the machine, see normalize.
+	Reproducible bool
+	// Dev constrains the generated files to builds without the embed_dev
+	// tag, the file generated by MakeDev takes their place with it.
+	Dev bool
+	// HTTP also generates an http.Handler serving the files of the fs.FS,
+	// it needs FS.
+	HTTP bool
+	// Precompress stores gzip compressed variants of the files of at least
+	// PrecompressMin bytes, 512 if 0, for the http.Handler to serve.
+	Precompress    bool
+	PrecompressMin int
+	// Fingerprint holds globs of files to archive under a name carrying a
+	// hash of their content, see fingerprint.
+	Fingerprint []string
+	// Fallback is the document the http.Handler serves for missing paths
+	// without an extension below one of FallbackPrefixes, all if none.
+	Fallback         string
+	FallbackPrefixes []string
+	// PerFile generates an accessor and data constant per file instead of
+	// a single function returning the archive, so the linker can drop the
+	// unused ones. PerFileTable adds a table of all of them.
+	PerFile      bool
+	PerFileTable bool
+	// Consts also generates a typed constant per file holding its path.
+	Consts bool
+	// SRI also generates the Subresource Integrity digests of the files.
+	SRI       bool
+	isTar     bool
+	archive   []byte            // uncompressed archive made by MakeTar
+	manifest  map[string]string // fingerprinted names by path, made by MakeTar
+	paths     []string          // regular files archived by MakeTar
+	inputHash string            // recorded in the generated source if set
+}
+
+// walkResult is what parsePath found under a single path.
+type walkResult struct {
+	index int // of the walked path
+	files []*Entry
+	err   error
+}
+
+// Entry is an opened input file and the slash separated path it is stored
+// under in the archive.
+type Entry struct {
+	File *os.File
+	Name string
+}
+
+// archiveName returns the archive path of p found while walking root.
+func (m *Maker) archiveName(root string, p string, info os.FileInfo) (string, error) {
+	rel, err := relName(root, p, info)
+	if err != nil || (p == root && !info.IsDir()) {
+		return rel, err
+	}
+	if m.KeepRoot {
+		abs, err := filepath.Abs(root)
+		if err != nil {
+			return "", err
+		}
+		rel = path.Join(filepath.Base(abs), rel)
+	}
+	return rel, nil
+}
+
+// relName returns the slash separated path of path relative to the walked
+// root, or its name if root is a file.
+func relName(root string, path string, info os.FileInfo) (string, error) {
+	if path == root && !info.IsDir() {
+		return info.Name(), nil
+	}
+	rel, err := filepath.Rel(root, path)
+	return filepath.ToSlash(rel), err
+}
+
+// parsePath walks p and sends the files to embed to out. Unreadable files
+// and directories do not stop the walk, their errors are joined.
+func (m *Maker) parsePath(ctx context.Context, index int, p string, out chan<- walkResult, wg *sync.WaitGroup) {
+	defer wg.Done()
+	var files []*Entry
+	var errs []error
+	err := m.walk(ctx, p, nil, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			errs = append(errs, err)
+			return nil
+		}
+		return m.addEntry(&files, &errs, p, path, info)
+	})
+	out <- walkResult{index: index, files: files, err: errors.Join(append(errs, err)...)}
+}
+
+// walk walks p applying the filters of m, calling fn for every file and
+// directory to embed and for every path that cannot be read, with its
+// error. loaded, if set, is called with every directory whose ignore files
+// are read.
+func (m *Maker) walk(ctx context.Context, p string, loaded func(dir string), fn filepath.WalkFunc) error {
+	ig := m.newIgnorer()
+	load := func(dir, rel string) error {
+		if loaded != nil {
+			loaded(dir)
+		}
+		return ig.load(dir, rel)
+	}
+	return filepath.Walk(p, func(path string, info os.FileInfo, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			return fn(path, info, err)
+		}
+		if path == p && info.IsDir() { //skip root if dir, unless kept as prefix
+			if err := load(path, "."); err != nil {
+				return err
+			}
+			if m.KeepRoot && !m.SkipDir {
+				return fn(path, info, nil)
+			}
+			return nil
+		}
+		rel, err := relName(filepath.Clean(p), filepath.Clean(path), info)
+		if err != nil {
+			return err
+		}
+		if excluded, err := matchAny(m.Exclude, rel); err != nil {
+			return err
+		} else if excluded {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if path != p {
+			if ignored, err := ig.ignored(rel, info.IsDir()); err != nil {
+				return err
+			} else if ignored {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if info.IsDir() {
+				if err := load(path, rel); err != nil {
+					return err
+				}
+			}
+		}
+		if !m.ParseHidden {
+			if r, _ := utf8.DecodeRuneInString(info.Name()); string(r) == "." {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
+		if !m.Recurssive {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+		}
+		if m.SkipDir {
+			if info.IsDir() {
+				return nil
+			}
+		}
+		if len(m.Include) > 0 {
+			// directories not included are still walked
+			if included, err := matchAny(m.Include, rel); err != nil || !included {
+				return err
+			}
+		}
+		return fn(path, info, nil)
+	})
+}
+
+// addEntry opens path and adds it to files, an unreadable file is added to
+// errs instead.
+func (m *Maker) addEntry(files *[]*Entry, errs *[]error, root string, path string, info os.FileInfo) error {
+	name, err := m.archiveName(filepath.Clean(root), filepath.Clean(path), info)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		*errs = append(*errs, err)
+		return nil
+	}
+	*files = append(*files, &Entry{File: f, Name: name})
+	return nil
+}
+
+func closeEntries(files []*Entry) {
+	for _, f := range files {
+		f.File.Close()
+	}
+}
+
+// MakeTar archives files, or copies a single file as is, and closes them.
+func (m *Maker) MakeTar(files []*Entry) (*bytes.Buffer, error) {
+	defer closeEntries(files)
+	buf := new(bytes.Buffer)
+	if len(files) == 1 && !m.FS && !m.PerFile && !m.Consts {
+		// skip tar process if only one file
+		if _, err := io.Copy(buf, files[0].File); err != nil {
+			return nil, err
+		}
+		return buf, nil
+	}
+	m.isTar = true
+
+	var epoch time.Time
+	if m.Reproducible {
+		var err error
+		if epoch, err = sourceDateEpoch(); err != nil {
+			return nil, err
+		}
+		sort.SliceStable(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	}
+
+	m.manifest, m.paths = make(map[string]string), nil
+	type archived struct {
+		path string
+		dir  bool
+	}
+	seen := make(map[string]archived, len(files))
+	tw := tar.NewWriter(buf)
+	for _, f := range files {
+		head, err := m.header(f, epoch)
+		if err != nil {
+			return nil, err
+		}
+		dir := head.Typeflag == tar.TypeDir
+		if prev, ok := seen[f.Name]; ok {
+			if prev.dir && dir {
+				continue // directory shared by several roots, archived once
+			}
+			return nil, fmt.Errorf("%s and %s both map to archive path %s", prev.path, f.File.Name(), f.Name)
+		}
+		seen[f.Name] = archived{f.File.Name(), dir}
+		fi := head.FileInfo()
+		if head.Typeflag == tar.TypeReg {
+			m.paths = append(m.paths, f.Name)
+		}
+		if err := m.fingerprint(f, head); err != nil {
+			return nil, err
+		}
+		if err := tw.WriteHeader(head); err != nil {
+			return nil, fmt.Errorf("archiving %s: %w", f.File.Name(), err)
+		}
+		if !fi.IsDir() {
+			if _, err := io.Copy(tw, f.File); err != nil {
+				return nil, fmt.Errorf("archiving %s: %w", f.File.Name(), err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	m.archive = buf.Bytes()
+	return buf, nil
+}
+
+// header returns the archive header of f, normalised against epoch if
+// m.Reproducible is set.
+func (m *Maker) header(f *Entry, epoch time.Time) (*tar.Header, error) {
+	fi, err := f.File.Stat()
+	if err != nil {
+		return nil, err
+	}
+	head, err := tar.FileInfoHeader(fi, "")
+	if err != nil {
+		return nil, fmt.Errorf("archiving %s: %w", f.File.Name(), err)
+	}
+	head.Name = f.Name
+	if fi.IsDir() {
+		head.Name += "/"
+	}
+	if m.Reproducible {
+		normalize(head, epoch)
+	}
+	return head, nil
+}
+
+// dataName returns the name of the generated function holding the stored,
+// possibly compressed, data.
+func (m *Maker) dataName(funcName string) string {
+	if m.Compression != "" {
+		return funcName + "Payload"
+	}
+	return funcName
+}
+
+// MakeSource returns the Go source of package packageName declaring
+// funcName() and the accessors selected by m over the data read from rawBuf.
+func (m *Maker) MakeSource(rawBuf *bytes.Buffer, packageName string, funcName string) (*bytes.Buffer, error) {
+	imports, err := m.sourceImports(funcName, rawBuf.Len())
+	if err != nil {
+		return nil, err
+	}
+	buf := new(bytes.Buffer)
+	buf.Grow(rawBuf.Len() * 2)
+	if m.Dev {
+		buf.WriteString(embeddedConstraint)
+	}
+	if _, err = fmt.Fprintf(buf, preTemplate, packageName, m.headerComments(funcName), importBlock(imports)); err != nil {
+		return nil, err
+	}
+	if err = m.writeSource(buf, rawBuf, funcName); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// sourceImports returns the packages imported by the source MakeSource
+// generates for funcName over size bytes of data.
+func (m *Maker) sourceImports(funcName string, size int) ([]string, error) {
+	c, err := m.codec()
+	if err != nil {
+		return nil, err
+	}
+	if m.PerFile {
+		return nil, m.checkPerFile()
+	}
+	var imports []string
+	if m.FS {
+		imports = append(imports, fsImports...)
+	}
+	if m.Precompress && !m.HTTP {
+		return nil, errors.New("precompressed variants need HTTP")
+	}
+	if m.HTTP {
+		if !m.FS {
+			return nil, errors.New("the http.Handler needs FS")
+		}
+		imports = append(imports, httpImports...)
+	}
+	if len(m.Fingerprint) > 0 || m.SRI {
+		imports = append(imports, "strings")
+	}
+	if m.Compression != "" {
+		imports = append(imports, "bytes", "io", "sync", c.pkg)
+	}
+	if m.ZeroCopy {
+		stringExpr, err := m.stringExpr(funcName, size)
+		if err != nil {
+			return nil, err
+		}
+		imports = append(imports, "strings")
+		if strings.HasPrefix(stringExpr, "unsafe.") {
+			imports = append(imports, "unsafe")
+		}
+	}
+	return imports, nil
+}
+
+// writeSource writes the declarations of the source MakeSource generates for
+// funcName over the data read from rawBuf to buf, following the package
+// clause and imports.
+func (m *Maker) writeSource(buf *bytes.Buffer, rawBuf *bytes.Buffer, funcName string) error {
+	if m.PerFile {
+		return m.writePerFileSource(buf, rawBuf, funcName)
+	}
+	c, err := m.codec()
+	if err != nil {
+		return err
+	}
+	isTarStr := ""
+	if m.isTar {
+		isTarStr = tarReminder
+	}
+	dataName, dataComment := m.dataName(funcName), isTarStr
+	if m.Compression != "" {
+		dataComment = fmt.Sprintf(payloadComment, funcName, funcName)
+	}
+
+	raw, err := ioutil.ReadAll(rawBuf)
+	if err != nil {
+		return err
+	}
+	var stringExpr string
+	if m.ZeroCopy {
+		if stringExpr, err = m.stringExpr(funcName, len(raw)); err != nil {
+			return err
+		}
+	}
+
+	if err = m.writeData(buf, dataComment, dataName, raw); err != nil {
+		return err
+	}
+
+	if m.Compression != "" {
+		comment := fmt.Sprintf(compressedReminder, m.Compression)
+		if isTarStr != "" {
+			comment = isTarStr + "\n" + comment
+		}
+		reader := fmt.Sprintf(c.reader, funcName)
+		if _, err = fmt.Fprintf(buf, decompressTemplate, funcName, reader, m.Compression, comment); err != nil {
+			return err
+		}
+	}
+
+	if m.ZeroCopy {
+		if _, err = fmt.Fprintf(buf, zeroCopyTemplate, funcName, stringExpr); err != nil {
+			return err
+		}
+	}
+
+	if m.FS {
+		snapshot := fmt.Sprintf(fsSnapshotTemplate, funcName)
+		if _, err = fmt.Fprintf(buf, fsTemplate, funcName, snapshot); err != nil {
+			return err
+		}
+	}
+
+	if m.Consts {
+		if err = m.writeConsts(buf, funcName, false); err != nil {
+			return err
+		}
+	}
+
+	if len(m.Fingerprint) > 0 || m.HTTP {
+		if _, err = fmt.Fprintf(buf, manifestTemplate, funcName, mapLiteral(m.manifest)); err != nil {
+			return err
+		}
+	}
+
+	if m.SRI {
+		archive, err := m.archiveData(raw)
+		if err != nil {
+			return err
+		}
+		sums, err := digests(funcName, archive, m.manifest)
+		if err != nil {
+			return err
+		}
+		if _, err = fmt.Fprintf(buf, sriTemplate, funcName, sums); err != nil {
+			return err
+		}
+	}
+
+	if m.HTTP {
+		archive, err := m.archiveData(raw)
+		if err != nil {
+			return err
+		}
+		tags, err := etags(archive)
+		if err != nil {
+			return err
+		}
+		if err = m.checkFallback(archive); err != nil {
+			return err
+		}
+		doc, prefixes := m.fallback()
+		if _, err = fmt.Fprintf(buf, httpTemplate, funcName, tags, doc, prefixes); err != nil {
+			return err
+		}
+		if err = m.writeGzipped(buf, funcName, archive); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeFile writes buf to the file name, replacing its content.
+func writeFile(name string, buf *bytes.Buffer) error {
+	file, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0664)
+	if err != nil {
+		return err
+	}
+	if _, err = buf.WriteTo(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// checkGenerated returns an error if the file name exists without the
+// header of a file generated by embed, so it is not overwritten.
+func checkGenerated(name string) error {
+	file, err := os.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer file.Close()
+	s := bufio.NewScanner(file)
+	for s.Scan() {
+		line := s.Text()
+		if line == "//autogenerated by embed" {
+			return nil
+		}
+		if strings.HasPrefix(line, "import") || strings.HasPrefix(line, "func") {
+			break // past the header
+		}
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("%s exists and was not generated by embed, refusing to overwrite it", name)
+}
@@ -0,0 +1,100 @@
+//
+// Copyright 2020 Alexander Saastamoinen
+//
+//  Licensed under the EUPL, Version 1.2 or – as soon they
+// will be approved by the European Commission - subsequent
+// versions of the EUPL (the "Licence");
+//  You may not use this work except in compliance with the
+// Licence.
+//  You may obtain a copy of the Licence at:
+//
+//  https://joinup.ec.europa.eu/collection/eupl/eupl-text-eupl-12
+//
+//  Unless required by applicable law or agreed to in
+// writing, software distributed under the Licence is
+// distributed on an "AS IS" basis,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied.
+//  See the Licence for the specific language governing
+// permissions and limitations under the Licence.
+//
+
+package bindata
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// initialisms are the words naming.initialisms writes in upper case.
+var initialisms = map[string]bool{
+	"API": true, "ASCII": true, "CSS": true, "CSV": true, "DNS": true, "EOF": true, "GIF": true,
+	"HTML": true, "HTTP": true, "HTTPS": true, "ICO": true, "ID": true, "IP": true, "JPEG": true,
+	"JPG": true, "JS": true, "JSON": true, "PDF": true, "PNG": true, "SQL": true, "SVG": true,
+	"TLS": true, "TTF": true, "UI": true, "URI": true, "URL": true, "UTF8": true, "UUID": true,
+	"WASM": true, "XML": true,
+}
+
+// naming derives Go identifiers from archive paths.
+type naming struct {
+	prefix string
+	// initialisms writes words of initialisms in upper case
+	initialisms bool
+	// suffixes are appended to each identifier to form the names declared
+	// for it, none may collide
+	suffixes []string
+	reserved []string
+}
+
+// idents returns an identifier for each of names, prefixed with n.prefix.
+// Names are split into words at everything but letters and digits, which
+// is dropped, and the words capitalised and joined, so static/css/main.css
+// becomes prefix + StaticCssMainCss, or StaticCSSMainCSS with
+// n.initialisms. Colliding identifiers get a number appended, 2 for the
+// first, in the order of names.
+func (n naming) idents(names []string) []string {
+	taken := make(map[string]bool)
+	for _, r := range n.reserved {
+		taken[r] = true
+	}
+	free := func(ident string) bool {
+		for _, s := range n.suffixes {
+			if taken[ident+s] {
+				return false
+			}
+		}
+		return true
+	}
+	idents := make([]string, len(names))
+	for i, name := range names {
+		var b strings.Builder
+		b.WriteString(n.prefix)
+		words := strings.FieldsFunc(name, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+		for _, w := range words {
+			if n.initialisms && initialisms[strings.ToUpper(w)] {
+				b.WriteString(strings.ToUpper(w))
+				continue
+			}
+			for j, r := range w {
+				if j == 0 {
+					r = unicode.ToUpper(r)
+				}
+				b.WriteRune(r)
+			}
+		}
+		base := b.String()
+		if len(words) == 0 {
+			base += "File"
+		}
+		ident := base
+		for c := 2; !free(ident); c++ {
+			ident = base + strconv.Itoa(c)
+		}
+		for _, s := range n.suffixes {
+			taken[ident+s] = true
+		}
+		idents[i] = ident
+	}
+	return idents
+}
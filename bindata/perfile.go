@@ -0,0 +1,142 @@
+//
+// Copyright 2020 Alexander Saastamoinen
+//
+//  Licensed under the EUPL, Version 1.2 or – as soon they
+// will be approved by the European Commission - subsequent
+// versions of the EUPL (the "Licence");
+//  You may not use this work except in compliance with the
+// Licence.
+//  You may obtain a copy of the Licence at:
+//
+//  https://joinup.ec.europa.eu/collection/eupl/eupl-text-eupl-12
+//
+//  Unless required by applicable law or agreed to in
+// writing, software distributed under the Licence is
+// distributed on an "AS IS" basis,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied.
+//  See the Licence for the specific language governing
+// permissions and limitations under the Licence.
+//
+
+package bindata
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+)
+
+const (
+	// perFileTemplate declares the accessor and data of a single file.
+	perFileTemplate string = `
+// %[1]s returns a copy of %[2]s.
+func %[1]s() []byte {
+	return []byte(%[1]sData)
+}
+
+// %[1]sData holds the content of %[2]s.
+const %[1]sData = `
+
+	// perFileTableTemplate declares the aggregate table, referencing every
+	// accessor and so keeping all files in the binary.
+	perFileTableTemplate string = `
+
+// %[1]sFiles maps the path of every embedded file to its accessor.
+var %[1]sFiles = map[string]func() []byte{
+`
+)
+
+// checkPerFile returns an error if m selects something needing the single
+// data blob next to PerFile.
+func (m *Maker) checkPerFile() error {
+	switch {
+	case m.Compression != "":
+		return errors.New("per file accessors cannot be compressed")
+	case m.Encoding == "asm":
+		return errors.New("per file accessors cannot use the asm encoding")
+	case m.FS:
+		return errors.New("per file accessors cannot be combined with the fs.FS or what builds on it")
+	case m.ZeroCopy:
+		return errors.New("per file accessors need no zero copy accessors, use their Data constants")
+	}
+	return nil
+}
+
+// writePerFileSource is writeSource for m.PerFile, rawBuf holds the archive.
+func (m *Maker) writePerFileSource(buf *bytes.Buffer, rawBuf *bytes.Buffer, funcName string) error {
+	raw, err := io.ReadAll(rawBuf)
+	if err != nil {
+		return err
+	}
+	archive, err := m.archiveData(raw)
+	if err != nil {
+		return err
+	}
+	if err = m.writePerFile(buf, funcName, archive); err != nil {
+		return err
+	}
+	if m.Consts {
+		return m.writeConsts(buf, funcName, false)
+	}
+	return nil
+}
+
+// writePerFile writes an accessor and a data constant for every regular file
+// of archive and, if m.PerFileTable is set, the table of all of them.
+func (m *Maker) writePerFile(buf *bytes.Buffer, funcName string, archive []byte) error {
+	contents := make(map[string][]byte)
+	var names []string
+	r := tar.NewReader(bytes.NewReader(archive))
+	for {
+		h, err := r.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := path.Clean(h.Name)
+		if contents[name], err = io.ReadAll(r); err != nil {
+			return err
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	reserved := []string{funcName + "Files"}
+	if m.Consts {
+		// declared next to the accessors by writeConsts
+		_, consts := m.constIdents(funcName)
+		reserved = append(append(reserved, funcName+"Asset"), consts...)
+	}
+	idents := naming{prefix: funcName, suffixes: []string{"", "Data"}, reserved: reserved}.idents(names)
+	for i, name := range names {
+		comment := name
+		if !strconv.CanBackquote(name) {
+			comment = strconv.Quote(name)
+		}
+		if _, err := fmt.Fprintf(buf, perFileTemplate, idents[i], comment); err != nil {
+			return err
+		}
+		writeStringLiteral(buf, contents[name])
+		buf.WriteByte('\n')
+	}
+	if !m.PerFileTable {
+		return nil
+	}
+	if _, err := fmt.Fprintf(buf, perFileTableTemplate, funcName); err != nil {
+		return err
+	}
+	for i, name := range names {
+		fmt.Fprintf(buf, "\t%q: %s,\n", name, idents[i])
+	}
+	buf.WriteString("}\n")
+	return nil
+}
@@ -0,0 +1,66 @@
+//
+// Copyright 2020 Alexander Saastamoinen
+//
+//  Licensed under the EUPL, Version 1.2 or – as soon they
+// will be approved by the European Commission - subsequent
+// versions of the EUPL (the "Licence");
+//  You may not use this work except in compliance with the
+// Licence.
+//  You may obtain a copy of the Licence at:
+//
+//  https://joinup.ec.europa.eu/collection/eupl/eupl-text-eupl-12
+//
+//  Unless required by applicable law or agreed to in
+// writing, software distributed under the Licence is
+// distributed on an "AS IS" basis,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied.
+//  See the Licence for the specific language governing
+// permissions and limitations under the Licence.
+//
+
+package bindata
+
+import (
+	"archive/tar"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// sourceDateEpoch returns the time set by the SOURCE_DATE_EPOCH environment
+// variable, or the Unix epoch if unset.
+func sourceDateEpoch() (time.Time, error) {
+	v := os.Getenv("SOURCE_DATE_EPOCH")
+	if v == "" {
+		return time.Unix(0, 0), nil
+	}
+	sec, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH: %w", err)
+	}
+	return time.Unix(sec, 0), nil
+}
+
+// normalize strips machine dependent fields from head: ownership is reset to
+// root, permissions to 0755 for directories and executables and 0644
+// otherwise, and the modification time clamped to epoch. With
+// SOURCE_DATE_EPOCH unset epoch is the Unix epoch, so every entry gets it.
+func normalize(head *tar.Header, epoch time.Time) {
+	head.Uid, head.Gid = 0, 0
+	head.Uname, head.Gname = "", ""
+	if head.Typeflag == tar.TypeDir || head.Mode&0111 != 0 {
+		head.Mode = 0755
+	} else {
+		head.Mode = 0644
+	}
+	if head.ModTime.After(epoch) {
+		head.ModTime = epoch
+	}
+	head.ModTime = head.ModTime.Truncate(time.Second)
+	head.AccessTime, head.ChangeTime = time.Time{}, time.Time{}
+	head.Devmajor, head.Devminor = 0, 0
+	head.Xattrs, head.PAXRecords = nil, nil
+	head.Format = tar.FormatUnknown
+}
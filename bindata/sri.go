@@ -0,0 +1,99 @@
+//
+// Copyright 2020 Alexander Saastamoinen
+//
+//  Licensed under the EUPL, Version 1.2 or – as soon they
+// will be approved by the European Commission - subsequent
+// versions of the EUPL (the "Licence");
+//  You may not use this work except in compliance with the
+// Licence.
+//  You may obtain a copy of the Licence at:
+//
+//  https://joinup.ec.europa.eu/collection/eupl/eupl-text-eupl-12
+//
+//  Unless required by applicable law or agreed to in
+// writing, software distributed under the Licence is
+// distributed on an "AS IS" basis,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied.
+//  See the Licence for the specific language governing
+// permissions and limitations under the Licence.
+//
+
+package bindata
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+// sriTemplate declares the Subresource Integrity digests of the archived
+// files, %[2]s is the literal of the digest map.
+const sriTemplate string = `
+
+// %[1]sDigest holds the Subresource Integrity strings of a file.
+type %[1]sDigest struct {
+	SHA256 string
+	SHA384 string
+}
+
+// %[1]sDigests holds the digests of the regular files of the archive,
+// computed when the file was generated. Fingerprinted files are listed
+// under both names. It is empty in development mode.
+var %[1]sDigests = %[2]s
+
+// %[1]sIntegrity returns the sha384 Subresource Integrity string of the file
+// at p, which may start with a slash, for use in integrity attributes.
+func %[1]sIntegrity(p string) (string, bool) {
+	d, ok := %[1]sDigests[strings.TrimPrefix(p, "/")]
+	return d.SHA384, ok
+}`
+
+// digests returns the source of the %[1]sDigests map of the regular files in
+// archive, fingerprinted ones also listed under their path in manifest.
+func digests(funcName string, archive []byte, manifest map[string]string) (string, error) {
+	sums := make(map[string]string)
+	r := tar.NewReader(bytes.NewReader(archive))
+	for {
+		h, err := r.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return "", err
+		}
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+		s256, s384 := sha256.New(), sha512.New384()
+		if _, err := io.Copy(io.MultiWriter(s256, s384), r); err != nil {
+			return "", err
+		}
+		sums[path.Clean(h.Name)] = fmt.Sprintf("{%q, %q}",
+			"sha256-"+base64.StdEncoding.EncodeToString(s256.Sum(nil)),
+			"sha384-"+base64.StdEncoding.EncodeToString(s384.Sum(nil)))
+	}
+	for name, hashed := range manifest {
+		if d, ok := sums[hashed]; ok {
+			sums[name] = d
+		}
+	}
+	names := make([]string, 0, len(sums))
+	for name := range sums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	fmt.Fprintf(&b, "map[string]%sDigest{\n", funcName)
+	for _, name := range names {
+		fmt.Fprintf(&b, "\t%q: %s,\n", name, sums[name])
+	}
+	b.WriteString("}")
+	return b.String(), nil
+}
@@ -0,0 +1,143 @@
+//
+// Copyright 2020 Alexander Saastamoinen
+//
+//  Licensed under the EUPL, Version 1.2 or – as soon they
+// will be approved by the European Commission - subsequent
+// versions of the EUPL (the "Licence");
+//  You may not use this work except in compliance with the
+// Licence.
+//  You may obtain a copy of the Licence at:
+//
+//  https://joinup.ec.europa.eu/collection/eupl/eupl-text-eupl-12
+//
+//  Unless required by applicable law or agreed to in
+// writing, software distributed under the Licence is
+// distributed on an "AS IS" basis,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied.
+//  See the Licence for the specific language governing
+// permissions and limitations under the Licence.
+//
+
+package bindata
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// fileState is what a poll of Watch compares to detect changes.
+type fileState struct {
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+}
+
+// snapshot returns the state of every file and directory m embeds from
+// paths, and of the ignore files read on the way, so files left out of the
+// archive do not trigger regenerations. Unreadable files are left out, they
+// show up as deleted. Directories only record their mode, their
+// modification time changes with every entry added or removed, which is
+// reported on its own.
+func snapshot(ctx context.Context, m *Maker, paths []string) map[string]fileState {
+	states := make(map[string]fileState)
+	record := func(path string, info os.FileInfo) {
+		if info.IsDir() {
+			states[path] = fileState{mode: info.Mode()}
+			return
+		}
+		states[path] = fileState{info.Size(), info.Mode(), info.ModTime()}
+	}
+	names := m.newIgnorer().names
+	for _, p := range paths {
+		m.walk(ctx, p, func(dir string) {
+			for _, n := range names {
+				if info, err := os.Stat(filepath.Join(dir, n)); err == nil {
+					record(filepath.Join(dir, n), info)
+				}
+			}
+		}, func(path string, info os.FileInfo, err error) error {
+			if err == nil {
+				record(path, info)
+			}
+			return nil
+		})
+	}
+	return states
+}
+
+// changes returns the sorted paths created, modified or deleted between
+// old and cur.
+func changes(old, cur map[string]fileState) []string {
+	var changed []string
+	for path, s := range cur {
+		if o, ok := old[path]; !ok || o.size != s.size || o.mode != s.mode || !o.modTime.Equal(s.modTime) {
+			changed = append(changed, path)
+		}
+	}
+	for path := range old {
+		if _, ok := cur[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// Watch generates the files for opts, then polls opts.Paths, or the paths of
+// opts.Groups, every interval and generates them again whenever files are
+// created, modified or deleted below them. Changes are collected until a
+// poll finds no further ones, so a burst of changes regenerates once.
+// report is called after every generation with the changed paths, nil for
+// the first, and the results of Generate. Watch returns ctx.Err() once ctx
+// is done.
+func Watch(ctx context.Context, opts Options, interval time.Duration, report func(changed []string, res Result, err error)) error {
+	m := opts.maker()
+	states := snapshot(ctx, m, opts.watched())
+	res, err := Generate(ctx, opts)
+	report(nil, res, err)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	pending := make(map[string]bool)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		cur := snapshot(ctx, m, opts.watched())
+		changed := changes(states, cur)
+		states = cur
+		for _, path := range changed {
+			pending[path] = true
+		}
+		if len(changed) > 0 || len(pending) == 0 {
+			// wait for the burst to settle
+			continue
+		}
+		changed = make([]string, 0, len(pending))
+		for path := range pending {
+			changed = append(changed, path)
+			delete(pending, path)
+		}
+		sort.Strings(changed)
+		res, err := Generate(ctx, opts)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		report(changed, res, err)
+	}
+}
+
+// watched returns the paths Watch polls for opts.
+func (opts Options) watched() []string {
+	paths := opts.Paths
+	for _, g := range opts.Groups {
+		paths = append(paths[:len(paths):len(paths)], g.Paths...)
+	}
+	return paths
+}
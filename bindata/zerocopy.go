@@ -0,0 +1,114 @@
+//
+// Copyright 2020 Alexander Saastamoinen
+//
+//  Licensed under the EUPL, Version 1.2 or – as soon they
+// will be approved by the European Commission - subsequent
+// versions of the EUPL (the "Licence");
+//  You may not use this work except in compliance with the
+// Licence.
+//  You may obtain a copy of the Licence at:
+//
+//  https://joinup.ec.europa.eu/collection/eupl/eupl-text-eupl-12
+//
+//  Unless required by applicable law or agreed to in
+// writing, software distributed under the Licence is
+// distributed on an "AS IS" basis,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied.
+//  See the Licence for the specific language governing
+// permissions and limitations under the Licence.
+//
+
+package bindata
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"unicode"
+)
+
+const (
+	zeroCopyTemplate string = `
+
+// %[1]sString returns the data of %[1]s() without copying it.
+func %[1]sString() string {
+	return %[2]s
+}
+
+// %[1]sReader returns an io.ReaderAt and io.ReadSeeker over the data of
+// %[1]s() without copying it.
+func %[1]sReader() *strings.Reader {
+	return strings.NewReader(%[1]sString())
+}`
+
+	benchTemplate string = `package %[1]s
+
+//autogenerated by embed
+
+import (
+	"io"
+	"testing"
+)
+
+var %[2]sSink int
+
+// Benchmark%[3]sCopy measures %[2]s(), which copies the data on every call.
+func Benchmark%[3]sCopy(b *testing.B) {
+	b.SetBytes(int64(len(%[2]sString())))
+	for i := 0; i < b.N; i++ {
+		%[2]sSink += len(%[2]s())
+	}
+}
+
+// Benchmark%[3]sString measures %[2]sString(), which does not copy.
+func Benchmark%[3]sString(b *testing.B) {
+	b.SetBytes(int64(len(%[2]sString())))
+	for i := 0; i < b.N; i++ {
+		%[2]sSink += len(%[2]sString())
+	}
+}
+
+// Benchmark%[3]sReader measures reading all data through %[2]sReader().
+func Benchmark%[3]sReader(b *testing.B) {
+	b.SetBytes(int64(len(%[2]sString())))
+	for i := 0; i < b.N; i++ {
+		n, err := io.Copy(io.Discard, %[2]sReader())
+		if err != nil {
+			b.Fatal(err)
+		}
+		%[2]sSink += int(n)
+	}
+}
+`
+)
+
+// stringExpr returns a Go expression evaluating to the data of funcName() as
+// a string backed by read-only storage, size is the length of the stored
+// data.
+func (m *Maker) stringExpr(funcName string, size int) (string, error) {
+	switch {
+	case m.Compression != "":
+		return funcName + "Decompressed()", nil
+	case m.Encoding == "string":
+		return funcName + "Literal", nil
+	case m.Encoding == "asm" && size == 0:
+		return `""`, nil
+	case m.Encoding == "asm":
+		return fmt.Sprintf("unsafe.String(&%[1]sAsm[0], len(%[1]sAsm))", funcName), nil
+	}
+	return "", errors.New("zero copy accessors need the string or asm encoding, or compression")
+}
+
+// MakeBench returns a test file benchmarking the copying accessor against
+// the zero copy ones generated with m.ZeroCopy.
+func (m *Maker) MakeBench(packageName string, funcName string) (*bytes.Buffer, error) {
+	if funcName == "" {
+		return nil, errors.New("empty function name")
+	}
+	buf := new(bytes.Buffer)
+	exported := []rune(funcName)
+	exported[0] = unicode.ToUpper(exported[0])
+	_, err := fmt.Fprintf(buf, benchTemplate, packageName, funcName, string(exported))
+	return buf, err
+}
@@ -0,0 +1,5 @@
+module github.com/miscing/embed
+
+go 1.21
+
+require golang.org/x/tools v0.14.0
@@ -0,0 +1,114 @@
+//
+// Copyright 2020 Alexander Saastamoinen
+//
+//  Licensed under the EUPL, Version 1.2 or – as soon they
+// will be approved by the European Commission - subsequent
+// versions of the EUPL (the "Licence");
+//  You may not use this work except in compliance with the
+// Licence.
+//  You may obtain a copy of the Licence at:
+//
+//  https://joinup.ec.europa.eu/collection/eupl/eupl-text-eupl-12
+//
+//  Unless required by applicable law or agreed to in
+// writing, software distributed under the Licence is
+// distributed on an "AS IS" basis,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied.
+//  See the Licence for the specific language governing
+// permissions and limitations under the Licence.
+//
+
+package main
+
+import (
+	"crypto/sha256"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/miscing/embed/bindata"
+)
+
+// ls runs the ls subcommand with args, listing the contents of generated
+// files. A file that cannot be listed is reported and the others are listed
+// regardless.
+func ls(args []string) error {
+	fs := flag.NewFlagSet("ls", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s ls [options] file.go...\nLists the archive entries embedded in files generated by embed with their size, mode, modification time and sha256, or describes the single file embedded.\n\nOptions:\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	name := fs.String("name", "", "function whose data to list, default every one found in the file")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	var failed int
+	for _, file := range fs.Args() {
+		if err := listFile(os.Stdout, file, *name); err != nil {
+			fmt.Fprintln(os.Stderr, "embed:", err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files could not be listed", failed, fs.NArg())
+	}
+	return nil
+}
+
+// listFile writes a description of the data of function name in file to w,
+// or of every function holding data if name is empty.
+func listFile(w io.Writer, file, name string) error {
+	names := []string{name}
+	if name == "" {
+		var err error
+		if names, err = bindata.SourceNames(file); err != nil {
+			return err
+		}
+		if len(names) == 0 {
+			return fmt.Errorf("%s: no embedded data found", file)
+		}
+	}
+	for _, n := range names {
+		p, err := bindata.ReadSource(file, n)
+		if err != nil {
+			return err
+		}
+		if err := listPayload(w, file, p); err != nil {
+			return fmt.Errorf("%s: %s: %w", file, n, err)
+		}
+	}
+	return nil
+}
+
+// listPayload writes a description of the data p read from file to w.
+func listPayload(w io.Writer, file string, p *bindata.Payload) error {
+	fmt.Fprintf(w, "%s: %s(), %s encoding", file, p.Name, p.Encoding)
+	if p.Compression != "" {
+		fmt.Fprintf(w, ", %d bytes %s compressed", len(p.Stored), p.Compression)
+	}
+	if !p.Archived && p.Files == nil {
+		fmt.Fprintf(w, ", single file of %d bytes, sha256 %x\n", len(p.Data), sha256.Sum256(p.Data))
+		return nil
+	}
+	entries, err := p.Entries()
+	if err != nil {
+		return err
+	}
+	if p.Files != nil {
+		// no headers, only the content is kept
+		fmt.Fprintf(w, ", per file accessors of %d files\n", len(entries))
+		for _, e := range entries {
+			fmt.Fprintf(w, "%10d %x %s\n", e.Size, e.Sum, e.Name)
+		}
+		return nil
+	}
+	fmt.Fprintf(w, ", tar archive of %d bytes with %d entries\n", len(p.Data), len(entries))
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s %10d %s %x %s\n", e.Mode, e.Size, e.ModTime.UTC().Format("2006-01-02 15:04:05"), e.Sum, e.Name)
+	}
+	return nil
+}
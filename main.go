@@ -22,229 +22,243 @@
 package main
 
 import (
-	"archive/tar"
-	"bytes"
+	"context"
 	"errors"
 	"flag"
 	"fmt"
-	"go/parser"
-	"go/token"
-	"io"
-	"io/ioutil"
-	"log"
 	"os"
-	"path/filepath"
-	"sync"
-	"unicode/utf8"
+	"os/signal"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/miscing/embed/bindata"
 )
 
 const (
-	usage       string = "embed [path(0)]... [path(i)]// embed path dir or file/s into current pwd package"
-	tarReminder string = "//variable contains a tar archive"
-	preTemplate string = `package %s
+	usage string = "embed [path(0)]... [path(i)]// embed path dir or file/s into current pwd package"
+)
 
-//autogenerated by embed
+// stringList is a repeatable string flag.
+type stringList []string
 
-%s
-func %s() []byte {
-	var bindata = []byte{`
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
 
-	postTemplate string = `}
-	return bindata
-}`
-)
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
 
-var ()
+// groupList is a repeatable name=path1,path2 flag.
+type groupList []bindata.Group
 
-func findPackageName() (name string, err error) {
-	fset := token.NewFileSet()
-	fMap, err := parser.ParseDir(fset, ".", nil, parser.PackageClauseOnly)
-	if err != nil {
-		return
-	}
-	if len(fMap) != 1 {
-		err = errors.New("expected only one package in current directory, found: " + string(len(fMap)))
-		return
+func (l *groupList) String() string {
+	var groups []string
+	for _, g := range *l {
+		groups = append(groups, g.Name+"="+strings.Join(g.Paths, ","))
 	}
-	for k := range fMap {
-		if k == "" {
-			err = errors.New("current pwd package has empty name")
-			return
-		}
-		name = k
-	}
-	return name, nil
+	return strings.Join(groups, " ")
 }
 
-func (m *Maker) OpenFiles(paths []string) (files []*os.File) {
-	out := make(chan *[]*os.File)
-	var wg sync.WaitGroup
-	for _, p := range paths {
-		wg.Add(1)
-		go m.parsePath(p, out, &wg)
-	}
-	go func() {
-		wg.Wait()
-		close(out)
-	}()
-	for fs := range out {
-		files = append(files, *fs...)
+func (l *groupList) Set(s string) error {
+	name, paths, ok := strings.Cut(s, "=")
+	if !ok || paths == "" {
+		return errors.New("want name=path1,path2")
 	}
-	return
+	*l = append(*l, bindata.Group{Name: name, Paths: strings.Split(paths, ",")})
+	return nil
 }
 
-type Maker struct {
-	SkipDir     bool
-	ParseHidden bool
-	Recurssive  bool
-	isTar       bool
+// fatal reports err and exits.
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "embed:", err)
+	os.Exit(1)
 }
 
-func (m *Maker) parsePath(p string, out chan *[]*os.File, wg *sync.WaitGroup) {
-	defer wg.Done()
-	var files []*os.File
-	if err := filepath.Walk(p, func(path string, info os.FileInfo, err error) error {
-		if path == p && info.IsDir() { //skip root if dir
-			return nil
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "ls" {
+		if err := ls(os.Args[2:]); err != nil {
+			fatal(err)
 		}
-		if !m.ParseHidden {
-			if r, _ := utf8.DecodeRuneInString(info.Name()); string(r) == "." {
-				if info.IsDir() {
-					return filepath.SkipDir
-				}
-				return nil
-			}
+		return
+	}
+	var opts bindata.Options
+	// set flags:
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [options] [path0] ... [pathi]\nGenerates a go source file for golang package in current directory containing all files found in given paths. Accessed through 'func bindata() []byte'. If multiple paths or path is a directory files will be packed into a tar archive.\nRun '%[1]s ls file.go' to list the contents of a generated file.\n\nOptions:\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.StringVar(&opts.Name, "name", "bindata", "sets generated source files data holding variable name, def bindata. Also sets fname to name + '.go'")
+	flag.StringVar(&opts.PackageName, "pname", "", "sets generated source files package name instead of parsing from current directories package")
+	fileName := flag.String("fname", "bindata.go", "sets generated source files name, default is bindata.go, use this to avoid overwritting")
+	flag.BoolVar(&opts.SkipDir, "skipdir", false, "directories are not added to outputed tar archive")
+	flag.BoolVar(&opts.ParseHidden, "phidden", false, "also encode hidden files.")
+	flag.BoolVar(&opts.Recursive, "r", false, "walk recurssively path")
+	flag.BoolVar(&opts.KeepRoot, "keeproot", false, "prefix archive paths with the name of the walked directory")
+	flag.Var((*stringList)(&opts.Include), "include", "only add files whose path relative to the walked path matches this glob, ** matches any number of directories, repeatable")
+	flag.Var((*stringList)(&opts.Exclude), "exclude", "leave out files and directories whose path relative to the walked path matches this glob, wins over -include, repeatable")
+	flag.BoolVar(&opts.GitIgnore, "gitignore", false, "also honour .gitignore files, .embedignore files are always honoured")
+	flag.StringVar(&opts.Compression, "compress", "", "compress data with gzip, zlib or flate, decompressed on first call of the generated function")
+	flag.IntVar(&opts.Level, "level", -1, "compression level for -compress from -2 to 9, -1 uses the default level, 0 stores the data uncompressed and -2 uses Huffman coding only")
+	flag.BoolVar(&opts.ZeroCopy, "zerocopy", false, "also generate name + 'String' and name + 'Reader' accessors sharing the read-only data instead of copying it, implies -encoding string unless set")
+	flag.BoolVar(&opts.Bench, "bench", false, "also generate a _test.go file next to fname benchmarking the copying accessor against the -zerocopy ones, implies -zerocopy")
+	flag.StringVar(&opts.Encoding, "encoding", "", "source encoding of the data, bytes for a []byte literal, string for a compact string constant or asm for an assembly file next to fname (default bytes, string with -zerocopy)")
+	flag.BoolVar(&opts.Reproducible, "reproducible", false, "sort archive entries and normalise owners, permissions and times so output is identical across machines, times are clamped to SOURCE_DATE_EPOCH if set")
+	flag.BoolVar(&opts.Dev, "dev", false, "also generate fname with a _dev suffix reading the paths from disk at run time, selected by building with -tags "+bindata.DevTag+" instead of the embedded data")
+	flag.BoolVar(&opts.HTTP, "http", false, "also generate an http.Handler named after name + 'Handler' serving the files of the -fs file system with caching headers and range support, implies -fs")
+	flag.BoolVar(&opts.Precompress, "precompress", false, "also store gzip variants of the files that are smaller compressed, served by the -http handler to clients accepting them, implies -http")
+	flag.IntVar(&opts.PrecompressMin, "precompressmin", 512, "files smaller than this many bytes get no -precompress variant")
+	flag.Var((*stringList)(&opts.Fingerprint), "fingerprint", "store files whose archive path matches this glob under a name carrying a hash of their content, listed in the generated name + 'Manifest' and looked up with name + 'AssetURL', implies -fs, repeatable")
+	flag.BoolVar(&opts.SRI, "sri", false, "also generate the sha256 and sha384 Subresource Integrity digests of the files and name + 'Integrity' returning the sha384 one by path, implies -fs")
+	flag.StringVar(&opts.Fallback, "fallback", "", "archive path of the document the -http handler serves for missing paths without an extension, for single page applications, implies -http")
+	flag.Var((*stringList)(&opts.FallbackPrefixes), "fallbackprefix", "URL path prefix -fallback applies to, repeatable, default /")
+	flag.BoolVar(&opts.PerFile, "perfile", false, "generate an accessor and data constant per file, named after name and the file path, instead of a single function returning the archive, so the linker drops unused files")
+	flag.BoolVar(&opts.PerFileTable, "perfiletable", false, "also generate name + 'Files' mapping every path to its -perfile accessor, which keeps all files in the binary, implies -perfile")
+	flag.BoolVar(&opts.Consts, "consts", false, "also generate a typed constant holding the archive path of every file, named after name + 'Asset' and the path as documented in the generated file, always archives even a single file")
+	flag.BoolVar(&opts.Check, "check", false, "do not write anything, exit with status 1 listing the changed archive entries if the generated files are not up to date")
+	flag.BoolVar(&opts.Force, "force", false, "regenerate even if the inputs hash recorded in the existing file matches")
+	flag.BoolVar(&opts.FS, "fs", false, "also generate an fs.FS named after name + 'FS' over the archive, always archives even a single file")
+	flag.Var((*groupList)(&opts.Groups), "group", "embed paths under an accessor of their own named name, given as name=path1,path2 instead of as arguments, repeatable, the groups share the other flags and are generated concurrently into fname")
+	flag.BoolVar(&opts.GroupFiles, "groupfiles", false, "generate each -group into a file named after it instead of into fname")
+	config := flag.String("config", "", "generate the targets listed in this JSON config file instead of the paths, flags given on the command line override its values")
+	watch := flag.Bool("watch", false, "keep running after generating, regenerate whenever files below the paths are created, modified or deleted")
+	interval := flag.Duration("interval", 500*time.Millisecond, "how often -watch polls the paths, changes are collected until a poll finds none")
+	flag.Parse()
+
+	opts.FileName = *fileName
+	if isSet("name") {
+		// derived from name, even over -fname
+		opts.FileName = ""
+	}
+	opts.Paths = flag.Args()
+	if opts.Level == 0 { // -level 0 stores the data uncompressed
+		opts.Level = bindata.NoCompression
+	}
+
+	targets := []bindata.Options{opts}
+	if *config != "" {
+		if len(opts.Paths) > 0 {
+			fatal(errors.New("-config takes no paths, list them in the config file"))
 		}
-		if !m.Recurssive {
-			if info.IsDir() {
-				return filepath.SkipDir
-			}
+		var err error
+		if targets, err = bindata.ReadConfig(*config); err != nil {
+			fatal(err)
 		}
-		if m.SkipDir {
-			if info.IsDir() {
-				return nil
-			}
+		for i := range targets {
+			override(&targets[i], opts)
 		}
-		f, err := os.Open(path)
-		if err != nil {
-			return err
+		if err := bindata.CheckOutputs(targets); err != nil {
+			fatal(fmt.Errorf("%s: %w", *config, err))
 		}
-		files = append(files, f)
-		return nil
-	}); err != nil {
-		log.Panic(err)
 	}
-	out <- &files
-}
 
-func (m *Maker) MakeTar(files []*os.File) *bytes.Buffer {
-	buf := new(bytes.Buffer)
-	if len(files) == 1 {
-		log.Println("only 1 file found, skipping tar archiving")
-		// skip tar process if only one file
-		_, err := io.Copy(buf, files[0])
-		if err != nil {
-			log.Panic(err)
+	if *watch {
+		if opts.Check {
+			fatal(errors.New("-watch and -check cannot be combined"))
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		errs := make(chan error, len(targets))
+		for _, t := range targets {
+			go func(t bindata.Options) {
+				errs <- bindata.Watch(ctx, t, *interval, func(changed []string, res bindata.Result, err error) {
+					switch {
+					case err != nil:
+						fmt.Fprintln(os.Stderr, "embed:", err)
+					case changed == nil:
+						report(t, res)
+					case !res.Unchanged:
+						fmt.Printf("%s regenerated %s: %s\n", time.Now().Format("15:04:05"), strings.Join(res.Files, ", "), strings.Join(changed, ", "))
+					}
+				})
+			}(t)
+		}
+		for range targets {
+			if err := <-errs; !errors.Is(err, context.Canceled) {
+				fatal(err)
+			}
 		}
-		return buf
+		return
 	}
-	m.isTar = true
 
-	tw := tar.NewWriter(buf)
-	for _, f := range files {
-		fi, err := f.Stat()
-		if err != nil {
-			log.Panic(err)
-		}
-		head, err := tar.FileInfoHeader(fi, "")
+	failed := false
+	for _, t := range targets {
+		res, err := bindata.Generate(context.Background(), t)
 		if err != nil {
-			log.Panic(err)
+			fmt.Fprintln(os.Stderr, "embed:", err)
+			failed = true
+			continue
 		}
-		if err := tw.WriteHeader(head); err != nil {
-			log.Panic(err)
+		report(t, res)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// configKeys maps the flags named unlike the config key of their Options
+// field to that key.
+var configKeys = map[string]string{
+	"pname":          "package",
+	"fname":          "output",
+	"phidden":        "hidden",
+	"r":              "recursive",
+	"fallbackprefix": "fallbackprefixes",
+	"group":          "groups",
+}
+
+// override sets the fields of target whose flags were given on the command
+// line to their value in opts. A target without an output of its own keeps
+// deriving its file name from its, possibly overridden, name.
+func override(target *bindata.Options, opts bindata.Options) {
+	src, dst := reflect.ValueOf(opts), reflect.ValueOf(target).Elem()
+	flag.Visit(func(f *flag.Flag) {
+		key := f.Name
+		if k, ok := configKeys[key]; ok {
+			key = k
 		}
-		if !fi.IsDir() {
-			if _, err := io.Copy(tw, f); err != nil {
-				log.Panic(err)
+		for i := 0; i < src.NumField(); i++ {
+			if src.Type().Field(i).Tag.Get("json") == key {
+				dst.Field(i).Set(src.Field(i))
 			}
 		}
-		f.Close()
-	}
-	if err := tw.Close(); err != nil {
-		log.Panic(err)
-	}
-	return buf
+	})
+	target.Check, target.Force = opts.Check, opts.Force
 }
 
-func (m *Maker) MakeSource(rawBuf *bytes.Buffer, packageName string, funcName string) *bytes.Buffer {
-	buf := new(bytes.Buffer)
-	isTarStr := ""
-	if m.isTar {
-		isTarStr = tarReminder
+// report prints the outcome of generating opts.
+func report(opts bindata.Options, res bindata.Result) {
+	if opts.Check {
+		fmt.Println("generated files are up to date")
+		return
 	}
-
-	_, err := fmt.Fprintf(buf, preTemplate, packageName, isTarStr, funcName)
-	if err != nil {
-		log.Panic(err)
+	if res.Unchanged {
+		fmt.Printf("%s up to date, use -force to regenerate\n", strings.Join(res.Files, ", "))
+		return
 	}
-
-	raw, err := ioutil.ReadAll(rawBuf)
-	if err != nil {
-		log.Panic(err)
+	if !res.Archived {
+		fmt.Println("only 1 file found, skipping tar archiving")
 	}
-	for _, b := range raw {
-		fmt.Fprintf(buf, "%#v, ", b)
+	if opts.Compression != "" && res.Size > 0 {
+		fmt.Printf("compressed %d to %d bytes with %s (%.1f%%)\n", res.Size, res.StoredSize, opts.Compression, float64(res.StoredSize)*100/float64(res.Size))
 	}
-
-	if _, err = fmt.Fprint(buf, postTemplate); err != nil {
-		log.Panic(err)
+	fmt.Printf("created %s for package %s containing:\n", strings.Join(res.Files, ", "), res.PackageName)
+	if len(opts.Groups) > 0 {
+		fmt.Println((*groupList)(&opts.Groups))
+		return
 	}
-
-	return buf
+	fmt.Println(opts.Paths)
 }
 
-func main() {
-	m := new(Maker)
-	// set flags:
-	flag.Usage = func() {
-		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [options] [path0] ... [pathi]\nGenerates a go source file for golang package in current directory containing all files found in given paths. Accessed through 'func bindata() []byte'. If multiple paths or path is a directory files will be packed into a tar archive.\n\nOptions:\n", os.Args[0])
-		flag.PrintDefaults()
-	}
-	funcName := flag.String("name", "bindata", "sets generated source files data holding variable name, def bindata. Also sets fname to name + '.go'")
-	packageName := flag.String("pname", "", "sets generated source files package name instead of parsing from current directories package")
-	fileName := flag.String("fname", "bindata.go", "sets generated source files name, default is bindata.go, use this to avoid overwritting")
-	flag.BoolVar(&m.SkipDir, "skipdir", false, "directories are not added to outputed tar archive")
-	flag.BoolVar(&m.ParseHidden, "phidden", false, "also encode hidden files.")
-	flag.BoolVar(&m.Recurssive, "r", false, "walk recurssively path")
-	flag.Parse()
-
-	if *packageName == "" {
-		if name, err := findPackageName(); err != nil {
-			log.Println("embed failed to find a package name to attach data to, quitting")
-			log.Panic(err)
-		} else {
-			*packageName = name
-		}
-	}
+// isSet reports whether the flag name was given on the command line.
+func isSet(name string) bool {
+	set := false
 	flag.Visit(func(f *flag.Flag) {
-		if f.Name == "name" {
-			*fileName = f.Value.String() + ".go"
+		if f.Name == name {
+			set = true
 		}
 	})
-
-	paths := flag.Args()
-	files := m.OpenFiles(paths)
-	tarBuf := m.MakeTar(files)
-	sourceFileBuff := m.MakeSource(tarBuf, *packageName, *funcName)
-	file, err := os.OpenFile(*fileName, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0664)
-	if err != nil {
-		log.Panic(err)
-	}
-	defer file.Close()
-	_, err = sourceFileBuff.WriteTo(file)
-	if err != nil {
-		log.Panic(err)
-	}
-	fmt.Printf("created %s for package %s containing:\n", *fileName, *packageName)
-	fmt.Println(paths)
+	return set
 }
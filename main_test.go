@@ -22,16 +22,15 @@
 package main
 
 import (
-	"archive/tar"
 	"bufio"
 	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"go/ast"
-	"go/parser"
-	"go/token"
-	"golang.org/x/tools/go/ast/inspector"
-	"io"
 	"io/ioutil"
 	"os"
 	"os/exec"
@@ -40,6 +39,9 @@ import (
 	"strings"
 	"testing"
 	"unicode/utf8"
+
+	"github.com/miscing/embed/bindata"
+	"github.com/miscing/embed/internal/testtree"
 )
 
 const (
@@ -50,6 +52,8 @@ var (
 	ignore string = "// +build ignore\n"
 	// testFiles []*testFile
 	tFiles testFiles
+	// embedBin is the embed command built by TestMain
+	embedBin string
 )
 
 type testFiles []*testFile
@@ -173,24 +177,6 @@ func isChild(root string, path string) bool {
 	return false
 }
 
-func walkTest() []*os.File {
-	var files []*os.File
-	if err := filepath.Walk(testDir, func(path string, info os.FileInfo, err error) error {
-		if testDir == path {
-			return nil
-		}
-		f, err := os.Open(path)
-		if err != nil {
-			panic(err)
-		}
-		files = append(files, f)
-		return nil
-	}); err != nil {
-		panic(err)
-	}
-	return files
-}
-
 func findTestFiles() testFiles {
 	var files []*testFile
 	if err := filepath.Walk(testDir, func(path string, info os.FileInfo, err error) error {
@@ -211,8 +197,11 @@ func findTestFiles() testFiles {
 				return nil
 			}
 		}
-		// files = append(files, &testFile{Name: filepath.Base(f.Name()), Content: string(c), IsHidden: isHidden(info.Name()), IsDir: info.IsDir(), IsChild: isChild(testDir, path)})
-		files = append(files, &testFile{Name: filepath.Base(f.Name()), Content: string(c), IsHidden: isHidden(testDir, path), IsDir: info.IsDir(), IsChild: isChild(testDir, path)})
+		rel, err := filepath.Rel(testDir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, &testFile{Name: filepath.ToSlash(rel), Content: string(c), IsHidden: isHidden(testDir, path), IsDir: info.IsDir(), IsChild: isChild(testDir, path)})
 		return nil
 	}); err != nil {
 		return nil
@@ -285,67 +274,6 @@ func printFiles(t *testing.T, hol, toTest []*testFile) {
 	}
 }
 
-func TestMakeTar(t *testing.T) {
-	files := walkTest()
-	m := new(Maker)
-	out := m.MakeTar(files)
-	r := tar.NewReader(out)
-	for {
-		h, err := r.Next()
-		if err == io.EOF {
-			return
-		} else if err != nil {
-			panic(err)
-		}
-		var mark bool
-		for _, f := range files {
-			if filepath.Base(f.Name()) == filepath.Clean(h.Name) {
-				mark = true
-				break
-			}
-		}
-		if !mark {
-			t.Log("File not found", h.Name)
-			t.Log("Test set:")
-			for _, f := range files {
-				t.Log("\t", f.Name())
-			}
-			t.Fatal("function output had file name not found in testdata/target")
-		}
-	}
-}
-
-func TestMakeSource(t *testing.T) {
-	const (
-		fName = "bindata"
-		pName = "main"
-	)
-	files := walkTest()
-	m := new(Maker)
-	out := m.MakeTar(files)
-	payload := m.MakeSource(out, pName, fName)
-	f, err := parser.ParseFile(token.NewFileSet(), "", payload, 0)
-	if err != nil {
-		t.Log(err)
-		t.Fatal("function output not valid go code")
-	}
-	inspector.New([]*ast.File{f}).Preorder([]ast.Node{
-		new(ast.FuncDecl),
-		new(ast.File),
-	}, func(n ast.Node) {
-		switch k := n.(type) {
-		case *ast.FuncDecl:
-			if k.Name.Name != fName {
-				t.Error("output func name: ", k.Name.Name)
-			}
-		case *ast.File:
-			if k.Name.Name != pName {
-				t.Error("output file name: ", k.Name.Name)
-			}
-		}
-	})
-}
-
 func TestSingleArg(t *testing.T) {
 	var err error
 	cmd := exec.Command("go", "run", "..", "./target/")
@@ -486,6 +414,20 @@ func TestName(t *testing.T) {
 	}
 }
 
+func TestNameOverFname(t *testing.T) {
+	target, err := filepath.Abs(testDir)
+	if err != nil {
+		panic(err)
+	}
+	dir := generate(t, "package main\n\nfunc main() {}\n", "-name", "foo", "-fname", "x.go", target)
+	if _, err := os.Stat(filepath.Join(dir, "foo.go")); err != nil {
+		t.Error("-name did not set the file name over -fname: ", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "x.go")); !os.IsNotExist(err) {
+		t.Error("-fname written although -name is given: ", err)
+	}
+}
+
 func TestSkipDir(t *testing.T) {
 	var err error
 	cmd := exec.Command("go", "run", "..", "-skipdir", "./target/")
@@ -547,13 +489,984 @@ func TestParseHiddenR(t *testing.T) {
 	os.Remove("./testdata/bindata.go")
 }
 
+func TestKeepRoot(t *testing.T) {
+	var err error
+	cmd := exec.Command("go", "run", "..", "-keeproot", "-r", "./target/")
+	cmd.Dir, err = filepath.Abs("./testdata/")
+	if err != nil {
+		panic(err)
+	}
+	err = cmd.Run()
+	if err != nil {
+		panic(err)
+	}
+	want := []*testFile{{Name: "target"}}
+	for _, f := range tFiles.Recurssive() {
+		want = append(want, &testFile{Name: "target/" + f.Name, Content: f.Content})
+	}
+	checkTestProgAgainst(t, want)
+
+	// the raw header names, which the test program cleans
+	p, err := bindata.ReadSource("./testdata/bindata.go", "bindata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := bindata.ListArchive(p.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if !strings.HasPrefix(e.Name, "target/") || strings.Contains(e.Name, "/./") {
+			t.Errorf("archive entry %q", e.Name)
+		}
+	}
+	if len(entries) == 0 || entries[0].Name != "target/" {
+		t.Errorf("root directory entry missing from %v", entries)
+	}
+	os.Remove("./testdata/bindata.go")
+}
+
+// runGenerated runs embed with args inside a fresh module holding prog as
+// main.go, then runs that program and returns its output.
+func runGenerated(t *testing.T, prog string, args ...string) string {
+	dir := generate(t, prog, args...)
+	cmd := exec.Command("go", "run", ".")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatal(cmd.String(), ": ", err, "\n", string(out))
+	}
+	return string(out)
+}
+
+// generate runs embed with args inside a fresh module holding prog as
+// main.go and returns the module directory.
+func generate(t *testing.T, prog string, args ...string) string {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module gentest\n\ngo 1.21\n"), 0664); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte(prog), 0664); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(embedBin, args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatal(cmd.String(), ": ", err, "\n", string(out))
+	}
+	return dir
+}
+
+func TestFS(t *testing.T) {
+	target, err := filepath.Abs(testDir)
+	if err != nil {
+		panic(err)
+	}
+	const prog = `package main
+
+import (
+	"fmt"
+	"io/fs"
+	"testing/fstest"
+)
+
+func main() {
+	if err := fstest.TestFS(assetsFS, "main.go", "somedir/main.go", "somedir/soma.txt", "empty.txt"); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fs.WalkDir(assetsFS, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, _ := d.Info()
+		fmt.Println(path, d.IsDir(), info.Size())
+		return nil
+	})
+}
+`
+	out := runGenerated(t, prog, "-fs", "-r", "-name", "assets", target)
+	want := ". true 0\nDockerfile false 50\nempty.txt false 0\nmain.go false 66\nsomedir true 0\nsomedir/main.go false 66\nsomedir/soma.txt false 240\nsomething.txt false 48\n"
+	if out != want {
+		t.Error("unexpected walk of generated fs.FS\nExpected:\n", want, "\nGot:\n", out)
+	}
+}
+
+func TestFSSingleFile(t *testing.T) {
+	target, err := filepath.Abs(testDir + "something.txt")
+	if err != nil {
+		panic(err)
+	}
+	const prog = `package main
+
+import (
+	"fmt"
+	"testing/fstest"
+)
+
+func main() {
+	if err := fstest.TestFS(bindataFS, "something.txt"); err != nil {
+		fmt.Println(err)
+	}
+}
+`
+	if out := runGenerated(t, prog, "-fs", target); out != "" {
+		t.Error(out)
+	}
+}
+
+func TestCompress(t *testing.T) {
+	target, err := filepath.Abs(testDir)
+	if err != nil {
+		panic(err)
+	}
+	const prog = `package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+)
+
+func main() {
+	fmt.Println(bindataCompression)
+	data := bindata()
+	data[0] = 0 // must not affect later calls
+	r := tar.NewReader(bytes.NewReader(bindata()))
+	for {
+		h, err := r.Next()
+		if err == io.EOF {
+			return
+		} else if err != nil {
+			panic(err)
+		}
+		fmt.Println(h.Name)
+	}
+}
+`
+	for _, c := range []string{"gzip", "zlib", "flate"} {
+		t.Run(c, func(t *testing.T) {
+			out := runGenerated(t, prog, "-compress", c, "-level", "9", target)
+			want := c + "\nDockerfile\nempty.txt\nmain.go\nsomething.txt\n"
+			if out != want {
+				t.Error("unexpected output of compressed data\nExpected:\n", want, "\nGot:\n", out)
+			}
+		})
+	}
+}
+
+func TestEncodingString(t *testing.T) {
+	var err error
+	cmd := exec.Command("go", "run", "..", "-encoding", "string", "./target/")
+	cmd.Dir, err = filepath.Abs("./testdata/")
+	if err != nil {
+		panic(err)
+	}
+	err = cmd.Run()
+	if err != nil {
+		panic(err)
+	}
+	checkTestProgAgainst(t, tFiles.Default())
+	os.Remove("./testdata/bindata.go")
+}
+
+func TestEncodingAsm(t *testing.T) {
+	for _, args := range [][]string{
+		{"-encoding", "asm"},
+		{"-encoding", "asm", "-compress", "gzip"},
+	} {
+		var err error
+		cmd := exec.Command("go", append(append([]string{"run", ".."}, args...), "./target/")...)
+		cmd.Dir, err = filepath.Abs("./testdata/")
+		if err != nil {
+			panic(err)
+		}
+		err = cmd.Run()
+		if err != nil {
+			panic(err)
+		}
+		if _, err := os.Stat("./testdata/bindata.s"); err != nil {
+			t.Error("asm encoding did not produce bindata.s: ", err)
+		}
+		checkTestProgAgainst(t, tFiles.Default())
+		os.Remove("./testdata/bindata.go")
+		os.Remove("./testdata/bindata.s")
+	}
+}
+
+func TestZeroCopy(t *testing.T) {
+	target, err := filepath.Abs(testDir)
+	if err != nil {
+		panic(err)
+	}
+	const prog = `package main
+
+import (
+	"fmt"
+	"io"
+)
+
+func main() {
+	s := bindataString()
+	if s != string(bindata()) {
+		fmt.Println("string accessor differs from bindata()")
+	}
+	r := bindataReader()
+	if _, err := r.Seek(-4, io.SeekEnd); err != nil {
+		panic(err)
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		panic(err)
+	}
+	if string(b) != s[len(s)-4:] {
+		fmt.Println("reader accessor differs from bindata()")
+	}
+	fmt.Println(len(s) > 0)
+}
+`
+	for _, args := range [][]string{
+		{"-zerocopy"},
+		{"-zerocopy", "-encoding", "asm"},
+		{"-zerocopy", "-encoding", "bytes", "-compress", "zlib"},
+	} {
+		t.Run(strings.Join(args, " "), func(t *testing.T) {
+			if out := runGenerated(t, prog, append(args, target)...); out != "true\n" {
+				t.Error(out)
+			}
+		})
+	}
+}
+
+func TestBench(t *testing.T) {
+	target, err := filepath.Abs(testDir)
+	if err != nil {
+		panic(err)
+	}
+	dir := generate(t, "package main\n\nfunc main() {}\n", "-bench", "-name", "assets", target)
+	cmd := exec.Command("go", "test", "-run", "^$", "-bench", ".", "-benchtime", "1x")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatal(cmd.String(), ": ", err, "\n", string(out))
+	}
+	for _, b := range []string{"BenchmarkAssetsCopy", "BenchmarkAssetsString", "BenchmarkAssetsReader"} {
+		if !strings.Contains(string(out), b) {
+			t.Error("generated benchmarks did not run ", b, "\n", string(out))
+		}
+	}
+
+	// a generated benchmark is replaced, one written by hand is not
+	cmd = exec.Command(embedBin, "-bench", "-force", "-name", "assets", target)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatal("regenerating the benchmark failed: ", err, "\n", string(out))
+	}
+	const own = "package main\n\nimport \"testing\"\n\nfunc TestOwn(t *testing.T) {}\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "assets_test.go"), []byte(own), 0664); err != nil {
+		t.Fatal(err)
+	}
+	cmd = exec.Command(embedBin, "-bench", "-force", "-name", "assets", target)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err == nil || !strings.Contains(string(out), "not generated by embed") {
+		t.Error("hand written assets_test.go not refused: ", err, "\n", string(out))
+	}
+	if b, err := ioutil.ReadFile(filepath.Join(dir, "assets_test.go")); err != nil || string(b) != own {
+		t.Error("hand written assets_test.go overwritten: ", err)
+	}
+}
+
+func TestDev(t *testing.T) {
+	src := testtree.Make(t, map[string]string{"a.txt": "a", "sub/b.txt": "b"})
+	const prog = `package main
+
+import (
+	"fmt"
+	"io/fs"
+)
+
+func main() {
+	b, err := fs.ReadFile(bindataFS, "sub/b.txt")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("%s %d\n", b, bindataReader().Len())
+}
+`
+	dir := generate(t, prog, "-dev", "-fs", "-r", "-zerocopy", "-compress", "gzip", src)
+	devModule(t, dir)
+	if err := ioutil.WriteFile(filepath.Join(src, "sub", "b.txt"), []byte("edited"), 0664); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"run", "."}, "b"},
+		{[]string{"run", "-tags", "embed_dev", "."}, "edited"},
+	} {
+		cmd := exec.Command("go", c.args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatal(cmd.String(), ": ", err, "\n", string(out))
+		}
+		if !strings.HasPrefix(string(out), c.want+" ") {
+			t.Errorf("%s printed %q, want %s", cmd, out, c.want)
+		}
+	}
+}
+
+// devModule makes the module in dir depend on this one, as the development
+// files generated with -dev import it.
+func devModule(t *testing.T, dir string) {
+	root, err := filepath.Abs(".")
+	if err != nil {
+		panic(err)
+	}
+	mod := "module gentest\n\ngo 1.21\n\nrequire github.com/miscing/embed v0.0.0\n\nreplace github.com/miscing/embed => " + root + "\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte(mod), 0664); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDevConsts(t *testing.T) {
+	// -consts archives even a single file, in development mode as well
+	src := testtree.Make(t, map[string]string{"a.txt": "a"})
+	const prog = `package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+)
+
+func main() {
+	fmt.Printf("%x %s\n", sha256.Sum256(bindata()), bindataAssetATxt)
+}
+`
+	dir := generate(t, prog, "-dev", "-consts", "-reproducible", src)
+	devModule(t, dir)
+	var outs []string
+	for _, args := range [][]string{{"run", "."}, {"run", "-tags", "embed_dev", "."}} {
+		cmd := exec.Command("go", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatal(cmd.String(), ": ", err, "\n", string(out))
+		}
+		outs = append(outs, string(out))
+	}
+	if outs[0] != outs[1] {
+		t.Errorf("embedded data %q, development mode %q", outs[0], outs[1])
+	}
+}
+
+func TestHTTP(t *testing.T) {
+	src := testtree.Make(t, map[string]string{"index.html": "<p>home</p>", "css/main.css": "p{}", "docs/index.html": "docs", "big.txt": strings.Repeat("0123456789", 100)})
+	const prog = `package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+)
+
+func get(path string, header ...string) *http.Response {
+	r := httptest.NewRequest("GET", path, nil)
+	for i := 0; i < len(header); i += 2 {
+		r.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	bindataHandler.ServeHTTP(w, r)
+	return w.Result()
+}
+
+func main() {
+	css := get("/css/main.css")
+	etag, modified := css.Header.Get("ETag"), css.Header.Get("Last-Modified")
+	fmt.Println(css.StatusCode, css.Header.Get("Content-Type"), css.Header.Get("Content-Length"), len(etag) > 2 && etag[0] == '"', modified != "")
+	fmt.Println(get("/css/main.css", "If-None-Match", etag).StatusCode)
+	fmt.Println(get("/css/main.css", "If-Modified-Since", modified).StatusCode)
+	big := get("/big.txt", "Range", "bytes=10-19")
+	fmt.Println(big.StatusCode, big.Header.Get("Content-Range"), big.Header.Get("Content-Length"))
+	index := get("/")
+	fmt.Println(index.StatusCode, index.Header.Get("Content-Type"), index.Header.Get("Content-Length"))
+	docs := get("/docs")
+	fmt.Println(docs.StatusCode, docs.Header.Get("Location"))
+	fmt.Println(get("/docs/").Header.Get("Content-Length"), get("/css/").StatusCode, get("/missing").StatusCode)
+}
+`
+	out := runGenerated(t, prog, "-http", "-r", src)
+	want := `200 text/css; charset=utf-8 3 true true
+304
+304
+206 bytes 10-19/1000 10
+200 text/html; charset=utf-8 11
+301 /docs/
+4 404 404
+`
+	if out != want {
+		t.Errorf("handler responses:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestPrecompress(t *testing.T) {
+	src := testtree.Make(t, map[string]string{"small.css": "p{}", "big.txt": strings.Repeat("0123456789", 100)})
+	const prog = `package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http/httptest"
+)
+
+func main() {
+	for _, c := range []struct{ path, accept string }{
+		{"/big.txt", "br;q=1.0, gzip;q=0.8"},
+		{"/big.txt", "gzip;q=0"},
+		{"/small.css", "gzip"},
+	} {
+		r := httptest.NewRequest("GET", c.path, nil)
+		r.Header.Set("Accept-Encoding", c.accept)
+		w := httptest.NewRecorder()
+		bindataHandler.ServeHTTP(w, r)
+		res := w.Result()
+		body := io.Reader(res.Body)
+		if res.Header.Get("Content-Encoding") == "gzip" {
+			var err error
+			if body, err = gzip.NewReader(body); err != nil {
+				panic(err)
+			}
+		}
+		b, err := io.ReadAll(body)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Printf("%d %q %q %q %d %v\n", res.StatusCode, res.Header.Get("Content-Encoding"), res.Header.Get("Vary"), res.Header.Get("Content-Type"), len(b), res.Header.Get("ETag") != "")
+	}
+	fmt.Println(len(bindataGzipped))
+}
+`
+	out := runGenerated(t, prog, "-precompress", "-precompressmin", "100", src)
+	want := `200 "gzip" "Accept-Encoding" "text/plain; charset=utf-8" 1000 true
+200 "" "Accept-Encoding" "text/plain; charset=utf-8" 1000 true
+200 "" "" "text/css; charset=utf-8" 3 true
+1
+`
+	if out != want {
+		t.Errorf("handler responses:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	src := testtree.Make(t, map[string]string{"index.html": "home", "static/app.js": "app()", "static/.hidden.js": "x"})
+	const prog = `package main
+
+import (
+	"fmt"
+	"net/http/httptest"
+)
+
+func main() {
+	url := bindataAssetURL("/static/app.js")
+	fmt.Println(url, bindataAssetURL("index.html"), len(bindataManifest))
+	for _, p := range []string{url, "/static/app.js", "/"} {
+		w := httptest.NewRecorder()
+		bindataHandler.ServeHTTP(w, httptest.NewRequest("GET", p, nil))
+		fmt.Printf("%d %q\n", w.Code, w.Header().Get("Cache-Control"))
+	}
+}
+`
+	out := runGenerated(t, prog, "-http", "-r", "-fingerprint", "**/*.js", src)
+	sum := sha256.Sum256([]byte("app()"))
+	hashed := "/static/app." + hex.EncodeToString(sum[:])[:10] + ".js"
+	want := hashed + ` index.html 1
+200 "public, max-age=31536000, immutable"
+404 ""
+200 ""
+`
+	if out != want {
+		t.Errorf("fingerprinted output:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestSRI(t *testing.T) {
+	src := testtree.Make(t, map[string]string{"style.css": "p{}", "static/app.js": "app()"})
+	const prog = `package main
+
+import "fmt"
+
+func main() {
+	for _, p := range []string{"/static/app.js", bindataAssetURL("static/app.js"), "style.css", "missing.css"} {
+		fmt.Println(bindataIntegrity(p))
+	}
+	fmt.Println(bindataDigests["style.css"].SHA256)
+}
+`
+	out := runGenerated(t, prog, "-sri", "-r", "-fingerprint", "**/*.js", src)
+	sri := func(data string) string {
+		sum := sha512.Sum384([]byte(data))
+		return "sha384-" + base64.StdEncoding.EncodeToString(sum[:])
+	}
+	sum := sha256.Sum256([]byte("p{}"))
+	want := fmt.Sprintf("%[1]s true\n%[1]s true\n%[2]s true\n false\nsha256-%[3]s\n", sri("app()"), sri("p{}"), base64.StdEncoding.EncodeToString(sum[:]))
+	if out != want {
+		t.Errorf("integrity output:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestFallback(t *testing.T) {
+	src := testtree.Make(t, map[string]string{"index.html": "app shell", "app.js": "app()", "docs/index.html": "docs"})
+	const prog = `package main
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+)
+
+func main() {
+	for _, p := range []string{"/app/users/1", "/app", "/app.js", "/app/missing.js", "/docs/", "/other/page"} {
+		w := httptest.NewRecorder()
+		bindataHandler.ServeHTTP(w, httptest.NewRequest("GET", p, nil))
+		fmt.Println(p, w.Code, strings.TrimSpace(w.Body.String()))
+	}
+}
+`
+	out := runGenerated(t, prog, "-fallback", "index.html", "-fallbackprefix", "/app", "-r", src)
+	want := `/app/users/1 200 app shell
+/app 200 app shell
+/app.js 200 app()
+/app/missing.js 404 404 page not found
+/docs/ 200 docs
+/other/page 404 404 page not found
+`
+	if out != want {
+		t.Errorf("fallback responses:\n%s\nwant:\n%s", out, want)
+	}
+
+	cmd := exec.Command(embedBin, "-pname", "assets", "-fallback", "missing.html", src)
+	cmd.Dir = t.TempDir()
+	if out, err := cmd.CombinedOutput(); err == nil || !strings.Contains(string(out), "missing.html") {
+		t.Error("missing fallback document not reported: ", err, string(out))
+	}
+}
+
+func TestPerFile(t *testing.T) {
+	// long enough not to be inlined as immediates
+	used, unused := strings.Repeat("used-marker-5d1c", 20), strings.Repeat("unused-marker-9e7a", 20)
+	src := testtree.Make(t, map[string]string{"icons/used.svg": used, "icons/unused.svg": unused})
+	const prog = `package main
+
+import "os"
+
+func main() {
+	os.Stdout.Write(bindataIconsUsedSvg())
+}
+`
+	for _, c := range []struct {
+		flag       string
+		wantUnused bool
+	}{{"-perfile", false}, {"-perfiletable", true}} {
+		dir := generate(t, prog, c.flag, "-r", src)
+		cmd := exec.Command("go", "build", "-o", "prog")
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatal(cmd.String(), ": ", err, "\n", string(out))
+		}
+		bin, err := ioutil.ReadFile(filepath.Join(dir, "prog"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		hasUsed, hasUnused := bytes.Contains(bin, []byte(used)), bytes.Contains(bin, []byte(unused))
+		if !hasUsed || hasUnused != c.wantUnused {
+			t.Errorf("%s: binary holds used file %v, unused file %v", c.flag, hasUsed, hasUnused)
+		}
+	}
+}
+
+func TestPerFileConsts(t *testing.T) {
+	// a file named asset clashes with the constants' type, asset/readme.txt
+	// with the constant of readme.txt
+	for _, files := range []map[string]string{
+		{"asset": "a", "other.txt": "o"},
+		{"asset/readme.txt": "nested", "readme.txt": "top"},
+	} {
+		src := testtree.Make(t, files)
+		dir := generate(t, "package main\n\nfunc main() {}\n", "-perfile", "-perfiletable", "-consts", "-r", src)
+		cmd := exec.Command("go", "vet", ".")
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Error(files, ": ", err, "\n", string(out))
+		}
+	}
+}
+
+func TestConsts(t *testing.T) {
+	src := testtree.Make(t, map[string]string{"static/css/main.css": "p{}", "static/js/app.js": "app()"})
+	const prog = `package main
+
+import (
+	"fmt"
+	"io/fs"
+)
+
+func main() {
+	b, err := fs.ReadFile(bindataFS, string(bindataAssetStaticCSSMainCSS))
+	fmt.Println(string(b), err, bindataAssetStaticJSAppJS)
+}
+`
+	dir := generate(t, prog, "-consts", "-fs", "-r", src)
+	cmd := exec.Command("go", "run", ".")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil || string(out) != "p{} <nil> static/js/app.js\n" {
+		t.Fatal(cmd.String(), ": ", err, "\n", string(out))
+	}
+	head, err := ioutil.ReadFile(filepath.Join(dir, "bindata.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(head, []byte("static/css/main.css becomes bindataAssetStaticCSSMainCSS")) {
+		t.Error("naming scheme not documented in the file header")
+	}
+
+	// referencing a removed file no longer compiles
+	if err := os.Remove(filepath.Join(src, "static", "css", "main.css")); err != nil {
+		t.Fatal(err)
+	}
+	regen := exec.Command(embedBin, "-consts", "-fs", "-r", src)
+	regen.Dir = dir
+	if out, err := regen.CombinedOutput(); err != nil {
+		t.Fatal(err, string(out))
+	}
+	cmd = exec.Command("go", "build", ".")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err == nil || !strings.Contains(string(out), "bindataAssetStaticCSSMainCSS") {
+		t.Error("removed file still compiles: ", err, string(out))
+	}
+}
+
+func TestGroups(t *testing.T) {
+	src := testtree.Make(t, map[string]string{"css/main.css": "p{}", "js/app.js": "app()", "js/lib.js": "lib()"})
+	const prog = `package main
+
+import (
+	"fmt"
+	"io/fs"
+)
+
+func main() {
+	css, err := fs.ReadFile(stylesFS, "main.css")
+	js, err2 := fs.ReadFile(scriptsFS, "lib.js")
+	fmt.Println(string(css), err, string(js), err2)
+}
+`
+	css, js := "styles="+filepath.Join(src, "css"), "scripts="+filepath.Join(src, "js")
+	for _, args := range [][]string{
+		{"-fs", "-group", css, "-group", js},
+		{"-http", "-sri", "-consts", "-zerocopy", "-compress", "gzip", "-group", css, "-group", js},
+		{"-fs", "-encoding", "asm", "-zerocopy", "-group", css, "-group", js},
+		{"-fs", "-groupfiles", "-group", css, "-group", js},
+	} {
+		dir := generate(t, prog, args...)
+		cmd := exec.Command("go", "run", ".")
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil || string(out) != "p{} <nil> lib() <nil>\n" {
+			t.Fatal(args, ": ", err, "\n", string(out))
+		}
+		want := []string{"bindata.go"}
+		if args[1] == "-groupfiles" {
+			want = []string{"styles.go", "scripts.go"}
+		}
+		for _, name := range want {
+			if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+				t.Error(args, ": ", err)
+			}
+		}
+	}
+
+	cmd := exec.Command(embedBin, "-group", css, src)
+	if out, err := cmd.CombinedOutput(); err == nil {
+		t.Error("paths and -group combined: ", string(out))
+	}
+}
+
+func TestConfig(t *testing.T) {
+	dir := testtree.Make(t, map[string]string{
+		"go.mod": "module gentest\n\ngo 1.21\n",
+		"main.go": `package main
+
+import (
+	"fmt"
+	"io/fs"
+)
+
+func main() {
+	css, err := fs.ReadFile(assetsFS, "main.css")
+	sql, err2 := fs.ReadFile(schemaFS, "schema.sql")
+	fmt.Println(string(css), err, string(sql), err2)
+}
+`,
+		"static/main.css":    "p{}",
+		"static/main.js":     "app()",
+		"gen/embed.json":     `{"targets": [{"name": "assets", "paths": ["../static"], "include": ["*.css"], "dir": ".."}, {"name": "schema", "paths": ["../schema.sql"], "dir": ".."}]}`,
+		"schema.sql":         "create table t;",
+		"gen/bad/embed.json": "{\"targets\": [{\"name\": \"a\", \"paths\": [\"a\"],\n\"hiden\": true}]}",
+	})
+	// -fs applies to every target, paths are relative to the config
+	cmd := exec.Command(embedBin, "-config", "gen/embed.json", "-fs")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatal(cmd.String(), ": ", err, "\n", string(out))
+	}
+	cmd = exec.Command("go", "run", ".")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil || string(out) != "p{} <nil> create table t; <nil>\n" {
+		t.Fatal(cmd.String(), ": ", err, "\n", string(out))
+	}
+
+	// -name keeps the outputs of the targets, and clashes are reported
+	// after overriding
+	outputs := filepath.Join(dir, "gen", "outputs.json")
+	if err := ioutil.WriteFile(outputs, []byte(`{"targets": [{"paths": ["../static"], "output": "a_data.go", "dir": ".."}, {"paths": ["../schema.sql"], "output": "b_data.go", "dir": ".."}]}`), 0664); err != nil {
+		t.Fatal(err)
+	}
+	cmd = exec.Command(embedBin, "-config", outputs, "-name", "zz", "-pname", "main")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatal(cmd.String(), ": ", err, "\n", string(out))
+	}
+	for _, name := range []string{"a_data.go", "b_data.go"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Error("-name dropped the output: ", err)
+		}
+	}
+	cmd = exec.Command(embedBin, "-config", "gen/embed.json", "-name", "zz")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err == nil || !strings.Contains(string(out), "target 2 writes") {
+		t.Error("overridden targets writing the same file: ", err, string(out))
+	}
+
+	cmd = exec.Command(embedBin, "-config", "gen/bad/embed.json")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err == nil || !strings.Contains(string(out), `gen/bad/embed.json:2:1: unknown key "hiden"`) {
+		t.Error("invalid config: ", err, string(out))
+	}
+}
+
+func TestLs(t *testing.T) {
+	src := testtree.Make(t, map[string]string{"css/main.css": "p{}", "js/app.js": "app()", "robots.txt": "Disallow:"})
+	dir := generate(t, "package main\n\nfunc main() {}\n", "-reproducible", "-compress", "gzip", "-encoding", "string",
+		"-group", "styles="+filepath.Join(src, "css")+","+filepath.Join(src, "js"), "-group", "robots="+filepath.Join(src, "robots.txt"))
+	cmd := exec.Command(embedBin, "ls", "bindata.go")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatal(cmd.String(), ": ", err, "\n", string(out))
+	}
+	sum := sha256.Sum256([]byte("p{}"))
+	for _, want := range []string{
+		"bindata.go: styles(), string encoding, ",
+		fmt.Sprintf("-rw-r--r--          3 1970-01-01 00:00:00 %x main.css\n", sum),
+		"bindata.go: robots(), string encoding, ",
+		fmt.Sprintf("single file of 9 bytes, sha256 %x\n", sha256.Sum256([]byte("Disallow:"))),
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("ls output lacks %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(string(out), "Payload") {
+		t.Errorf("ls lists the compressed data as a function:\n%s", out)
+	}
+
+	cmd = exec.Command(embedBin, "ls", "-name", "robots", "bindata.go")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil || strings.Contains(string(out), "styles") {
+		t.Error("ls -name robots: ", err, string(out))
+	}
+
+	// a file without data is reported, the files after it still listed
+	cmd = exec.Command(embedBin, "ls", "main.go", "bindata.go")
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err == nil {
+		t.Error("ls succeeded on a file without data")
+	}
+	if !strings.Contains(stderr.String(), "main.go: no embedded data found") {
+		t.Errorf("ls did not report main.go:\n%s", stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "bindata.go: styles(), ") || !strings.Contains(stdout.String(), "bindata.go: robots(), ") {
+		t.Errorf("ls stopped at main.go:\n%s", stdout.String())
+	}
+}
+
+func TestLsPerFile(t *testing.T) {
+	src := testtree.Make(t, map[string]string{"css/main.css": "p{}", "js/app.js": "app()"})
+	dir := generate(t, "package main\n\nfunc main() {}\n", "-perfile", "-r", src)
+	cmd := exec.Command(embedBin, "ls", "bindata.go")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatal(cmd.String(), ": ", err, "\n", string(out))
+	}
+	want := fmt.Sprintf("bindata.go: bindata(), string encoding, per file accessors of 2 files\n"+
+		"         3 %x css/main.css\n         5 %x js/app.js\n", sha256.Sum256([]byte("p{}")), sha256.Sum256([]byte("app()")))
+	if string(out) != want {
+		t.Errorf("ls printed\n%s\nwant\n%s", out, want)
+	}
+
+	// -check lists the changed files
+	if err := ioutil.WriteFile(filepath.Join(src, "js", "app.js"), []byte("app(1)"), 0664); err != nil {
+		t.Fatal(err)
+	}
+	cmd = exec.Command(embedBin, "-check", "-perfile", "-r", src)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err == nil || !strings.Contains(string(out), "0 added, 0 removed, 1 changed\n\tchanged js/app.js") {
+		t.Error("-check of per file accessors: ", err, string(out))
+	}
+}
+
+func TestIncludeExclude(t *testing.T) {
+	for _, c := range []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"-exclude", "somedir", "-include", "**/*.txt"}, []string{"empty.txt", "something.txt"}},
+		{[]string{"-skipdir", "-include", "**/*.go"}, []string{"main.go", "somedir/main.go"}},
+		{[]string{"-skipdir", "-exclude", "**/main.go", "-exclude", "*.txt"}, []string{"Dockerfile", "somedir/soma.txt"}},
+	} {
+		var err error
+		cmd := exec.Command("go", append(append([]string{"run", "..", "-r"}, c.args...), "./target/")...)
+		cmd.Dir, err = filepath.Abs("./testdata/")
+		if err != nil {
+			panic(err)
+		}
+		err = cmd.Run()
+		if err != nil {
+			panic(err)
+		}
+		var want []*testFile
+		for _, f := range tFiles {
+			for _, n := range c.want {
+				if f.Name == n {
+					want = append(want, f)
+				}
+			}
+		}
+		checkTestProgAgainst(t, want)
+		os.Remove("./testdata/bindata.go")
+	}
+}
+
+func TestEmbedIgnore(t *testing.T) {
+	files := map[string]string{
+		".embedignore":      "# comment\n*.log\n!keep.log\nbuild/\n/secret.txt\nvendor/**\n",
+		".gitignore":        "a.txt\n",
+		"a.txt":             "a",
+		"a.log":             "a log",
+		"keep.log":          "kept",
+		"secret.txt":        "secret",
+		"c.txt":             "c",
+		"build/out.txt":     "built",
+		"docs/build":        "not a directory",
+		"vendor/v.txt":      "vendored",
+		"sub/.embedignore":  "!b.log\nc.txt\n",
+		"sub/secret.txt":    "not anchored here",
+		"sub/b.log":         "re-included",
+		"sub/c.txt":         "ignored in sub only",
+		"sub/deep/a.log":    "ignored again",
+		"sub/deep/keep.log": "kept again",
+	}
+	dir := testtree.Make(t, files)
+	for _, c := range []struct {
+		args []string
+		want []string
+	}{
+		{nil, []string{"a.txt", "keep.log", "c.txt", "docs/build", "sub/secret.txt", "sub/b.log", "sub/deep/keep.log"}},
+		{[]string{"-gitignore"}, []string{"keep.log", "c.txt", "docs/build", "sub/secret.txt", "sub/b.log", "sub/deep/keep.log"}},
+	} {
+		var err error
+		cmd := exec.Command("go", append(append([]string{"run", "..", "-r", "-skipdir"}, c.args...), dir)...)
+		cmd.Dir, err = filepath.Abs("./testdata/")
+		if err != nil {
+			panic(err)
+		}
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatal(err, string(out))
+		}
+		var want []*testFile
+		for _, n := range c.want {
+			want = append(want, &testFile{Name: n, Content: files[n]})
+		}
+		checkTestProgAgainst(t, want)
+		os.Remove("./testdata/bindata.go")
+	}
+}
+
+func TestUnreadableFiles(t *testing.T) {
+	dir := testtree.Make(t, map[string]string{"ok.txt": "ok"})
+	if err := os.Symlink(filepath.Join(dir, "missing"), filepath.Join(dir, "broken")); err != nil {
+		t.Skip(err)
+	}
+	cmd := exec.Command(embedBin, dir)
+	cmd.Dir, _ = filepath.Abs("./testdata/")
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Error("embed succeeded with unreadable files")
+		os.Remove("./testdata/bindata.go")
+	}
+	if strings.Contains(string(out), "goroutine") || !strings.Contains(string(out), "broken") {
+		t.Error("unexpected error output: ", string(out))
+	}
+}
+
+func TestCheck(t *testing.T) {
+	src := testtree.Make(t, map[string]string{"a.txt": "a", "b.txt": "b"})
+	dir := generate(t, "package main\n\nfunc main() {}\n", "-reproducible", src)
+	check := func() (string, error) {
+		cmd := exec.Command(embedBin, "-check", "-reproducible", src)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		return string(out), err
+	}
+	if out, err := check(); err != nil {
+		t.Fatal("up to date output reported: ", err, out)
+	}
+	if err := ioutil.WriteFile(filepath.Join(src, "b.txt"), []byte("changed"), 0664); err != nil {
+		t.Fatal(err)
+	}
+	out, err := check()
+	var exit *exec.ExitError
+	if !errors.As(err, &exit) || exit.ExitCode() != 1 {
+		t.Fatal("drift did not exit with status 1: ", err, out)
+	}
+	if !strings.Contains(out, "changed b.txt") || strings.Contains(out, "a.txt") {
+		t.Error("unexpected drift summary: ", out)
+	}
+}
+
 func TestMain(m *testing.M) {
 	tFiles = findTestFiles()
 
+	dir, err := ioutil.TempDir("", "embed")
+	if err != nil {
+		panic(err)
+	}
+	embedBin = filepath.Join(dir, "embed")
+	if out, err := exec.Command("go", "build", "-o", embedBin, ".").CombinedOutput(); err != nil {
+		panic(string(out))
+	}
+
 	os.Remove("./testdata/bindata.go")
+	os.Remove("./testdata/bindata.s")
 	r := m.Run()
 	os.Remove("./testdata/bindata.go")
+	os.Remove("./testdata/bindata.s")
 	os.Remove("./testdata/readypacked/archive.tar")
+	os.RemoveAll(dir)
 
 	os.Exit(r)
 }
@@ -25,7 +25,8 @@ func main() {
 	var buf bytes.Buffer
 	var path string
 
-	name := flag.String("-n", "archive", "set output name, .tar is appended to it")
+	// named n, not -n: flag panics on names starting with a dash
+	name := flag.String("n", "archive", "set output name, .tar is appended to it")
 	flag.Parse()
 	if flag.NArg() > 1 {
 		usage()
@@ -38,7 +39,8 @@ func main() {
 	}
 
 	tw := tar.NewWriter(&buf)
-	err := filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
+	root := path
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
 		if info.IsDir() {
 			return nil //skips dir, but will still search recurssively into it
 		}
@@ -54,6 +56,11 @@ func main() {
 		if err != nil {
 			log.Panic(err) // stop execution
 		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			log.Panic(err)
+		}
+		head.Name = filepath.ToSlash(rel)
 		if err := tw.WriteHeader(head); err != nil {
 			log.Panic(err)
 		}